@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kelproject/kel-go"
+)
+
+// apiClient calls the Kel API endpoints that kel-go does not wrap. Requests
+// and responses are JSON API documents.
+type apiClient struct {
+	hc      *http.Client
+	baseURL string
+}
+
+// apiResource is a JSON API resource object.
+type apiResource struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id,omitempty"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+type apiDocument struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []apiError      `json:"errors,omitempty"`
+	Links  struct {
+		Next string `json:"next,omitempty"`
+	} `json:"links"`
+}
+
+type apiError struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func setupAPIClient(uri URI) *apiClient {
+	return &apiClient{
+		hc:      setupAuth(),
+		baseURL: apiBaseURL(uri),
+	}
+}
+
+// Do sends a request for the resource at path. When attributes is not nil
+// it is sent as a resource of the given type. The attributes of the
+// response resource are decoded into out when it is not nil.
+func (c *apiClient) Do(method, path, resourceType string, attributes, out interface{}) error {
+	var body io.Reader
+	if attributes != nil {
+		attrs, err := json.Marshal(attributes)
+		if err != nil {
+			return err
+		}
+		buf, err := json.Marshal(map[string]apiResource{
+			"data": {Type: resourceType, Attributes: attrs},
+		})
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, c.url(path), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.api+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/vnd.api+json")
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	doc, err := decodeAPIResponse(resp)
+	if err != nil || out == nil || len(doc.Data) == 0 {
+		return err
+	}
+	var resource apiResource
+	if err := json.Unmarshal(doc.Data, &resource); err != nil {
+		return err
+	}
+	return json.Unmarshal(resource.Attributes, out)
+}
+
+// List fetches the resource collection at path into out, which must be a
+// pointer to a slice. Pagination links are followed until the last page.
+func (c *apiClient) List(path string, out interface{}) error {
+	var attributes []json.RawMessage
+	for next := c.url(path); next != ""; {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/vnd.api+json")
+		resp, err := c.hc.Do(req)
+		if err != nil {
+			return err
+		}
+		doc, err := decodeAPIResponse(resp)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		var resources []apiResource
+		if err := json.Unmarshal(doc.Data, &resources); err != nil {
+			return err
+		}
+		for _, resource := range resources {
+			attributes = append(attributes, resource.Attributes)
+		}
+		next = ""
+		if doc.Links.Next != "" {
+			next = c.url(doc.Links.Next)
+		}
+	}
+	buf, err := json.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, out)
+}
+
+// Stream requests the resource at path and returns the response body for
+// the caller to read incrementally, such as a log stream.
+func (c *apiClient) Stream(path string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", c.url(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		_, err := decodeAPIResponse(resp)
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *apiClient) url(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.baseURL + path
+}
+
+// decodeAPIResponse decodes a response document, turning error responses
+// into errors.
+func decodeAPIResponse(resp *http.Response) (*apiDocument, error) {
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, kel.ErrNotFound
+	}
+	var doc apiDocument
+	if resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid response (%s): %v", resp.Status, err)
+		}
+	}
+	if resp.StatusCode >= 400 {
+		if len(doc.Errors) > 0 {
+			e := doc.Errors[0]
+			if e.Detail != "" {
+				return nil, fmt.Errorf("%s: %s", resp.Status, e.Detail)
+			}
+			return nil, fmt.Errorf("%s: %s", resp.Status, e.Title)
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return &doc, nil
+}
+
+// sitePath returns the API path of the site named by uri.
+func sitePath(uri URI) string {
+	return fmt.Sprintf("/resource-groups/%s/sites/%s/", uri.ResourceGroup, uri.Site)
+}
+
+// resourceGroupPath returns the API path of a resource group.
+func resourceGroupPath(resourceGroup string) string {
+	return fmt.Sprintf("/resource-groups/%s/", resourceGroup)
+}
+
+// sitesPath returns the API path of the sites of a resource group.
+func sitesPath(resourceGroup string) string {
+	return fmt.Sprintf("/resource-groups/%s/sites/", resourceGroup)
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+var flagManifest string
+
+func init() {
+	RootCmd.AddCommand(planCmd, applyCmd)
+	planCmd.Flags().StringVarP(&flagManifest, "file", "f", ManifestFile, "Manifest file")
+	planCmd.Flags().BoolVarP(&flagReveal, "reveal", "", false, "Show the values of secret variables")
+	applyCmd.Flags().StringVarP(&flagManifest, "file", "f", ManifestFile, "Manifest file")
+	applyCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Apply without asking for confirmation")
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes kel apply would make",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plan [-f <file>]\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		plan := loadPlan(flagManifest)
+		if flagOutput == OutputJSON {
+			printJSON(plan.Changes)
+			return
+		}
+		if len(plan.Changes) == 0 {
+			fmt.Printf("%s/%s is up to date.\n", plan.URI.ResourceGroup, plan.URI.Site)
+			return
+		}
+		plan.print()
+	},
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Change the site to match its manifest",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel apply [-f <file>] [--yes]\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		manifest, err := loadManifest(flagManifest)
+		if err != nil {
+			fatal(fmt.Sprintf("failed to load %s (error: %v)", flagManifest, err))
+		}
+		plan, err := planManifest(manifest)
+		if err != nil {
+			fatal(err.Error())
+		}
+		if len(plan.Changes) == 0 {
+			success(fmt.Sprintf("%s/%s is up to date.", plan.URI.ResourceGroup, plan.URI.Site))
+			return
+		}
+		plan.print()
+		if !flagYes && !confirm("Apply these changes?") {
+			fatal("apply cancelled.")
+		}
+		if skipped := applyPlan(manifest, plan); len(skipped) > 0 {
+			fatal(fmt.Sprintf("%s/%s does not match %s yet; skipped %s.", plan.URI.ResourceGroup, plan.URI.Site, flagManifest, strings.Join(skipped, ", ")))
+		}
+		success(fmt.Sprintf("%s/%s matches %s.", plan.URI.ResourceGroup, plan.URI.Site, flagManifest))
+	},
+}
+
+func loadPlan(filename string) *manifestPlan {
+	manifest, err := loadManifest(filename)
+	if err != nil {
+		fatal(fmt.Sprintf("failed to load %s (error: %v)", filename, err))
+	}
+	plan, err := planManifest(manifest)
+	if err != nil {
+		fatal(err.Error())
+	}
+	return plan
+}
+
+// applyPlan makes the changes of a plan, creating the resource group and
+// site first. Env changes are made as a single release. It returns the
+// changes that could not be made yet.
+func applyPlan(manifest *Manifest, plan *manifestPlan) []string {
+	var skipped []string
+	uri := plan.URI
+	kc := setupKelClient(uri)
+	api := setupAPIClient(uri)
+	if !plan.resourceGroupExists {
+		resourceGroup := kel.ResourceGroup{Name: uri.ResourceGroup}
+		if err := kc.ResourceGroups.Create(&resourceGroup).Do(); err != nil {
+			fatal(fmt.Sprintf("failed to create resource group (error: %v)", err))
+		}
+		fmt.Printf("Created resource group %q\n", uri.ResourceGroup)
+	}
+	if !plan.siteExists {
+		var resourceGroup kel.ResourceGroup
+		if err := kc.ResourceGroups.Get(uri.ResourceGroup, &resourceGroup).Do(); err != nil {
+			fatal(fmt.Sprintf("failed to get resource group (error: %v)", err))
+		}
+		site := kel.Site{ResourceGroup: &resourceGroup, Name: uri.Site}
+		if err := kc.Sites.Create(&site).Do(); err != nil {
+			fatal(fmt.Sprintf("failed to create site (error: %v)", err))
+		}
+		fmt.Printf("Created site %q\n", uri.ResourceGroup+"/"+uri.Site)
+	}
+
+	if changes := plan.changes("env"); len(changes) > 0 {
+		change := &envChange{Set: make(map[string]string)}
+		for _, c := range changes {
+			if c.Action == ChangeDelete {
+				change.Unset = append(change.Unset, c.Name)
+			} else {
+				change.Set[c.Name] = manifest.Env[c.Name]
+				// keep variables secret on the site secret
+				if plan.secrets[c.Name] {
+					change.Secret = append(change.Secret, c.Name)
+				}
+			}
+		}
+		applyEnv(uri, change)
+	}
+
+	if changes := plan.changes("scale"); len(changes) > 0 {
+		scale := make(map[string]int)
+		delta := 0
+		for _, c := range changes {
+			current, ok := plan.formation[c.Name]
+			if !ok {
+				warning(fmt.Sprintf("site has no %q process type yet; deploy it and apply again.", c.Name))
+				skipped = append(skipped, "scale "+c.Name)
+				continue
+			}
+			scale[c.Name] = manifest.Scale[c.Name]
+			delta += manifest.Scale[c.Name] - current
+		}
+		if len(scale) > 0 {
+			checkInstanceQuota(api, uri, delta)
+			if err := api.Do("PATCH", sitePath(uri)+"formation/", "formation", map[string]interface{}{"instances": scale}, nil); err != nil {
+				fatal(fmt.Sprintf("failed to scale site (error: %v)", err))
+			}
+			for _, processType := range sortedKeys(scale) {
+				fmt.Printf("Scaled %s to %s\n", processType, whiteBold(strconv.Itoa(scale[processType])))
+			}
+		}
+	}
+
+	for _, c := range plan.changes("domain") {
+		if c.Action == ChangeDelete {
+			if err := api.Do("DELETE", domainPath(uri, c.Name), "", nil, nil); err != nil {
+				fatal(fmt.Sprintf("failed to remove domain %s (error: %v)", c.Name, err))
+			}
+			fmt.Printf("Removed domain %s\n", c.Name)
+			continue
+		}
+		var domain domainDetails
+		if err := api.Do("POST", sitePath(uri)+"domains/", "domains", map[string]string{"name": c.Name}, &domain); err != nil {
+			fatal(fmt.Sprintf("failed to add domain %s (error: %v)", c.Name, err))
+		}
+		fmt.Printf("Added domain %s\n", c.Name)
+		for _, record := range domain.Records {
+			fmt.Printf("  create DNS record: %s %s %s\n", record.Type, record.Name, record.Value)
+		}
+	}
+
+	if changes := plan.changes("plugin"); len(changes) > 0 {
+		siteConfig := manifestSiteConfig(uri)
+		if siteConfig == nil {
+			warning(fmt.Sprintf("plugins are enabled for directories activated for the site; run kel activate %s/%s and apply again.", uri.ResourceGroup, uri.Site))
+			for _, c := range changes {
+				skipped = append(skipped, "plugin "+c.Name)
+			}
+			return skipped
+		}
+		for _, c := range changes {
+			resolution, err := ResolvePlugin(c.Name, c.To)
+			if err != nil {
+				fatal(err.Error())
+			}
+			if resolution.Plugin == nil {
+				plugin, err := resolveIndexPlugin(c.Name, c.To)
+				if err != nil {
+					fatal(err.Error())
+				}
+				if err := plugin.Install(); err != nil {
+					fatal(fmt.Sprintf("failed to install plugin %q (error: %v)", plugin.Name, err))
+				}
+				config.AddPlugin(plugin)
+			}
+			siteConfig.EnablePlugin(c.Name, c.To)
+			fmt.Printf("Enabled plugin %s %s\n", c.Name, c.To)
+		}
+		config.Save()
+	}
+	return skipped
+}
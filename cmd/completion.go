@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	kelplugin "github.com/kelproject/kel/plugin"
+	"github.com/spf13/cobra"
+)
+
+// bashCompletionFunction asks plugins for dynamic completions when cobra has
+// none to offer.
+const bashCompletionFunction = `
+__kel_plugin_complete()
+{
+    local out
+    out=$(kel __complete-plugin "${words[@]:1:$((cword-1))}" "${cur}" 2>/dev/null) || return
+    COMPREPLY=( $(compgen -W "${out}" -- "${cur}") )
+}
+
+__custom_func()
+{
+    __kel_plugin_complete
+}
+`
+
+func init() {
+	RootCmd.BashCompletionFunction = bashCompletionFunction
+	RootCmd.AddCommand(completionCmd)
+	RootCmd.AddCommand(completePluginCmd)
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion",
+	Short: "Output bash completion code",
+	Long: `Output bash completion code for kel, including the plugins of the
+activated site. Load it in the current shell with:
+
+    source <(kel completion)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := RootCmd.GenBashCompletion(os.Stdout); err != nil {
+			fatal(fmt.Sprintf("failed to generate completion (error: %v)", err))
+		}
+	},
+}
+
+var completePluginCmd = &cobra.Command{
+	Use:                "__complete-plugin",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		i, plugin := findPluginCommand(args, RootCmd.PersistentFlags(), loadedPlugins)
+		if plugin == nil {
+			return
+		}
+		if !completes(plugin.Description()) {
+			return
+		}
+		out, err := plugin.query(append([]string{kelplugin.CompleteFlag}, args[i+1:]...)...)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+	},
+}
+
+// completes reports whether any command or flag in description supports
+// dynamic completion.
+func completes(description *kelplugin.Description) bool {
+	if description == nil {
+		return false
+	}
+	if description.Complete {
+		return true
+	}
+	for _, flag := range description.Flags {
+		if flag.Complete {
+			return true
+		}
+	}
+	for _, sub := range description.Commands {
+		if completes(sub) {
+			return true
+		}
+	}
+	return false
+}
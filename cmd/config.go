@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"strconv"
 	"sync"
 
 	"github.com/spf13/cobra"
@@ -23,14 +24,25 @@ const (
 type Config struct {
 	DefaultCluster *URI                     `json:"cluster,omitempty"`
 	Auth           string                   `json:"auth,omitempty"`
+	PluginExec     string                   `json:"plugin_exec,omitempty"`
 	Sites          map[string]*SiteConfig   `json:"sites"`
 	Tokens         map[string]*oauth2.Token `json:"tokens"`
 	Plugins        map[string]*Plugin       `json:"plugins"`
+	PluginIndexes  []string                 `json:"plugin_indexes,omitempty"`
+	// GlobalPlugins maps plugins available in every directory to their
+	// version range. Sites pinning the same plugin override it.
+	GlobalPlugins map[string]string `json:"global_plugins,omitempty"`
+	// DisableUpdateCheck turns off plugin update notices.
+	DisableUpdateCheck bool `json:"disable_update_check,omitempty"`
 }
 
 type SiteConfig struct {
 	URI     *URI              `json:"uri,omitempty"`
 	Plugins map[string]string `json:"plugins"`
+	// Linked maps plugins linked for development to the version range they
+	// replaced and LinkedPlugins holds their manifests.
+	Linked        map[string]string  `json:"linked,omitempty"`
+	LinkedPlugins map[string]*Plugin `json:"linked_plugins,omitempty"`
 }
 
 var config *Config
@@ -115,6 +127,16 @@ var configGetCmd = &cobra.Command{
 		case "auth":
 			fmt.Println(config.Auth)
 			break
+		case "plugin-exec":
+			if config.PluginExec == "" {
+				fmt.Println(PluginExecSubprocess)
+			} else {
+				fmt.Println(config.PluginExec)
+			}
+			break
+		case "update-check":
+			fmt.Println(!config.DisableUpdateCheck)
+			break
 		}
 	},
 }
@@ -152,6 +174,24 @@ var configSetCmd = &cobra.Command{
 				fatal("invalid authentication type")
 			}
 			break
+		case "plugin-exec":
+			switch args[1] {
+			case PluginExecSubprocess, PluginExecReplace:
+				config.PluginExec = args[1]
+				config.Save()
+				break
+			default:
+				fatal(fmt.Sprintf("invalid plugin exec mode (must be %s or %s)", PluginExecSubprocess, PluginExecReplace))
+			}
+			break
+		case "update-check":
+			enabled, err := strconv.ParseBool(args[1])
+			if err != nil {
+				fatal("invalid update check setting (must be true or false)")
+			}
+			config.DisableUpdateCheck = !enabled
+			config.Save()
+			break
 		}
 	},
 }
@@ -220,10 +260,24 @@ func (config *Config) Save() {
 	}
 }
 
+// EnableGlobalPlugin will make the named plugin available in every
+// directory using the highest installed version in versionRange.
+func (config *Config) EnableGlobalPlugin(name, versionRange string) {
+	if config.GlobalPlugins == nil {
+		config.GlobalPlugins = make(map[string]string)
+	}
+	config.GlobalPlugins[name] = versionRange
+}
+
 // AddPlugin will add the given plugin to the site config.
 func (siteConfig *SiteConfig) AddPlugin(plugin *Plugin) {
+	siteConfig.EnablePlugin(plugin.Name, fmt.Sprintf("=%s", plugin.Version))
+}
+
+// EnablePlugin will pin the named plugin to versionRange for the site.
+func (siteConfig *SiteConfig) EnablePlugin(name, versionRange string) {
 	if siteConfig.Plugins == nil {
 		siteConfig.Plugins = make(map[string]string)
 	}
-	siteConfig.Plugins[plugin.Name] = fmt.Sprintf("=%s", plugin.Version)
+	siteConfig.Plugins[name] = versionRange
 }
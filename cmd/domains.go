@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagCertFile string
+	flagKeyFile  string
+	flagManaged  bool
+)
+
+// domainDetails is a custom domain of a site.
+type domainDetails struct {
+	Name        string      `json:"name"`
+	Verified    bool        `json:"verified"`
+	Status      string      `json:"status"`
+	Certificate string      `json:"certificate"`
+	Records     []dnsRecord `json:"records"`
+}
+
+// dnsRecord is a DNS record the owner of a domain must create.
+type dnsRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// certificateUpload is a certificate for a domain. Managed certificates are
+// issued and renewed by the cluster.
+type certificateUpload struct {
+	Managed     bool   `json:"managed"`
+	Certificate string `json:"certificate,omitempty"`
+	Key         string `json:"key,omitempty"`
+}
+
+func init() {
+	RootCmd.AddCommand(domainsCmd, certsCmd)
+	domainsCmd.AddCommand(
+		domainsListCmd,
+		domainsAddCmd,
+		domainsRemoveCmd,
+	)
+	certsCmd.AddCommand(certsAddCmd)
+	certsAddCmd.Flags().StringVarP(&flagCertFile, "cert", "", "", "PEM file of the certificate chain")
+	certsAddCmd.Flags().StringVarP(&flagKeyFile, "key", "", "", "PEM file of the private key")
+	certsAddCmd.Flags().BoolVarP(&flagManaged, "managed", "", false, "Let the cluster issue and renew the certificate")
+}
+
+var domainsCmd = &cobra.Command{
+	Use:   "domains",
+	Short: "Manage domains of the activated site",
+}
+
+var domainsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List domains and their verification status",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel domains list\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		var domains []*domainDetails
+		if err := setupAPIClient(uri).List(sitePath(uri)+"domains/", &domains); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to list domains (error: %v)", err))
+		}
+		if flagOutput == OutputJSON {
+			printJSON(domains)
+			return
+		}
+		w := newTabWriter()
+		fmt.Fprintf(w, "DOMAIN\tSTATUS\tCERTIFICATE\n")
+		for _, domain := range domains {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", domain.Name, domain.statusText(), domain.Certificate)
+		}
+		w.Flush()
+	},
+}
+
+var domainsAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a domain to the activated site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel domains add <domain>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		domain := domainDetails{Name: strings.ToLower(args[0])}
+		if err := setupAPIClient(uri).Do("POST", sitePath(uri)+"domains/", "domains", map[string]string{"name": domain.Name}, &domain); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to add domain (error: %v)", err))
+		}
+		if flagOutput == OutputJSON {
+			printJSON(domain)
+			return
+		}
+		success(fmt.Sprintf("added %s to %s/%s.", domain.Name, uri.ResourceGroup, uri.Site))
+		if len(domain.Records) > 0 {
+			fmt.Println("Create these DNS records to verify and route the domain:")
+			w := newTabWriter()
+			for _, record := range domain.Records {
+				fmt.Fprintf(w, "  %s\t%s\t%s\n", record.Type, record.Name, record.Value)
+			}
+			w.Flush()
+		}
+	},
+}
+
+var domainsRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a domain from the activated site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel domains remove <domain>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		name := strings.ToLower(args[0])
+		if err := setupAPIClient(uri).Do("DELETE", domainPath(uri, name), "", nil, nil); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("domain %s is not added to %s/%s.", name, uri.ResourceGroup, uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to remove domain (error: %v)", err))
+		}
+		success(fmt.Sprintf("removed %s from %s/%s.", name, uri.ResourceGroup, uri.Site))
+	},
+}
+
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Manage TLS certificates of the activated site",
+}
+
+var certsAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a TLS certificate for a domain",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel certs add <domain> --cert <file> --key <file>\n")
+			fmt.Fprintf(os.Stderr, "       kel certs add <domain> --managed\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		name := strings.ToLower(args[0])
+		upload := certificateUpload{Managed: flagManaged}
+		if flagManaged {
+			if flagCertFile != "" || flagKeyFile != "" {
+				usage("--managed cannot be combined with --cert or --key")
+			}
+		} else {
+			if flagCertFile == "" || flagKeyFile == "" {
+				usage("--cert and --key are required unless --managed is given")
+			}
+			certPEM, err := ioutil.ReadFile(flagCertFile)
+			if err != nil {
+				fatal(fmt.Sprintf("failed to read certificate (error: %v)", err))
+			}
+			keyPEM, err := ioutil.ReadFile(flagKeyFile)
+			if err != nil {
+				fatal(fmt.Sprintf("failed to read key (error: %v)", err))
+			}
+			if err := checkCertificate(certPEM, keyPEM, name, time.Now()); err != nil {
+				fatal(err.Error())
+			}
+			upload.Certificate = string(certPEM)
+			upload.Key = string(keyPEM)
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		if err := setupAPIClient(uri).Do("PUT", domainPath(uri, name)+"certificate/", "certificates", &upload, nil); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("domain %s is not added to %s/%s (add it with kel domains add).", name, uri.ResourceGroup, uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to add certificate (error: %v)", err))
+		}
+		if flagManaged {
+			success(fmt.Sprintf("a managed certificate will be issued for %s once the domain is verified.", name))
+			return
+		}
+		success(fmt.Sprintf("added certificate for %s.", name))
+	},
+}
+
+// domainPath returns the API path of a domain of the site named by uri.
+func domainPath(uri URI, name string) string {
+	return fmt.Sprintf("%sdomains/%s/", sitePath(uri), name)
+}
+
+func (domain *domainDetails) statusText() string {
+	if domain.Verified {
+		return green("verified")
+	}
+	if domain.Status != "" {
+		return yellow(domain.Status)
+	}
+	return yellow("pending verification")
+}
+
+// checkCertificate verifies that the certificate matches the key, covers
+// the domain and is currently valid.
+func checkCertificate(certPEM, keyPEM []byte, domain string, now time.Time) error {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("certificate and key do not match (%v)", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid certificate (%v)", err)
+	}
+	if err := leaf.VerifyHostname(domain); err != nil {
+		return fmt.Errorf("certificate does not cover %s (%v)", domain, err)
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("certificate is not valid until %s", formatTime(leaf.NotBefore))
+	}
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate expired on %s", formatTime(leaf.NotAfter))
+	}
+	return nil
+}
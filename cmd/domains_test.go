@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed PEM certificate for names valid
+// between notBefore and notAfter and its PEM key.
+func testCertificate(t *testing.T, names []string, notBefore, notAfter time.Time) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+func TestCheckCertificate(t *testing.T) {
+	now := time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+	notBefore := now.AddDate(0, -1, 0)
+	notAfter := now.AddDate(0, 1, 0)
+	certPEM, keyPEM := testCertificate(t, []string{"example.com", "*.example.com"}, notBefore, notAfter)
+	_, otherKeyPEM := testCertificate(t, []string{"example.com"}, notBefore, notAfter)
+	tests := []struct {
+		name   string
+		key    []byte
+		domain string
+		now    time.Time
+		err    string
+	}{
+		{"valid", keyPEM, "example.com", now, ""},
+		{"wildcard", keyPEM, "www.example.com", now, ""},
+		{"other key", otherKeyPEM, "example.com", now, "do not match"},
+		{"other domain", keyPEM, "example.org", now, "does not cover"},
+		{"nested subdomain", keyPEM, "a.b.example.com", now, "does not cover"},
+		{"not yet valid", keyPEM, "example.com", notBefore.Add(-time.Second), "not valid until"},
+		{"expired", keyPEM, "example.com", notAfter.Add(time.Second), "expired"},
+	}
+	for _, test := range tests {
+		err := checkCertificate(certPEM, test.key, test.domain, test.now)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: checkCertificate() = %v, want nil", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: checkCertificate() = %v, want error containing %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestCheckCertificateInvalidPEM(t *testing.T) {
+	if err := checkCertificate([]byte("not a certificate"), []byte("not a key"), "example.com", time.Now()); err == nil {
+		t.Errorf("checkCertificate() with invalid PEM = nil, want error")
+	}
+}
@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	EnvFormatDotenv = "dotenv"
+	EnvFormatJSON   = "json"
+	EnvFormatShell  = "shell"
+)
+
+var (
+	flagReveal    bool
+	flagSecret    bool
+	flagEnvFormat string
+)
+
+// envVar is a configuration variable of a site.
+type envVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// envChange is a batch of configuration changes applied as one release.
+type envChange struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Secret []string          `json:"secret,omitempty"`
+	Unset  []string          `json:"unset,omitempty"`
+}
+
+func init() {
+	RootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(
+		envListCmd,
+		envGetCmd,
+		envSetCmd,
+		envUnsetCmd,
+		envImportCmd,
+		envExportCmd,
+	)
+	envCmd.PersistentFlags().BoolVarP(&flagReveal, "reveal", "", false, "Show the values of secret variables")
+	envSetCmd.Flags().BoolVarP(&flagSecret, "secret", "", false, "Mark the variables as secret")
+	envImportCmd.Flags().BoolVarP(&flagSecret, "secret", "", false, "Mark the variables as secret")
+	envExportCmd.Flags().StringVarP(&flagEnvFormat, "format", "", EnvFormatDotenv, "Output format (dotenv, json or shell)")
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage configuration variables of a site",
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configuration variables",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel env list [--reveal]\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		vars := fetchEnv(envSiteURI())
+		if flagOutput == OutputJSON {
+			printJSON(maskEnv(vars))
+			return
+		}
+		w := newTabWriter()
+		for _, v := range vars {
+			fmt.Fprintf(w, "%s\t%s\n", v.Name, v.display())
+		}
+		w.Flush()
+	},
+}
+
+var envGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Print the value of a configuration variable",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel env get [--reveal] <name>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		for _, v := range fetchEnv(envSiteURI()) {
+			if v.Name == args[0] {
+				fmt.Println(v.display())
+				return
+			}
+		}
+		fatal(fmt.Sprintf("variable %q is not set.", args[0]))
+	},
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set configuration variables",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel env set [--secret] <name>=<value>...\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		vars := make(map[string]string)
+		for _, arg := range args {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 || !validEnvName(parts[0]) {
+				usage(fmt.Sprintf("invalid assignment %q", arg))
+			}
+			vars[parts[0]] = parts[1]
+		}
+		applyEnv(envSiteURI(), newEnvChange(vars, flagSecret))
+	},
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset",
+	Short: "Remove configuration variables",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel env unset <name>...\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		applyEnv(envSiteURI(), &envChange{Unset: args})
+	},
+}
+
+var envImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Set configuration variables from a .env file",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel env import [--secret] <file>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		f, err := os.Open(args[0])
+		if err != nil {
+			fatal(fmt.Sprintf("failed to open %s (error: %v)", args[0], err))
+		}
+		vars, err := parseDotenv(f)
+		f.Close()
+		if err != nil {
+			fatal(fmt.Sprintf("failed to parse %s (error: %v)", args[0], err))
+		}
+		if len(vars) == 0 {
+			fatal(fmt.Sprintf("no variables found in %s.", args[0]))
+		}
+		applyEnv(envSiteURI(), newEnvChange(vars, flagSecret))
+	},
+}
+
+var envExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print configuration variables as dotenv, JSON or shell",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel env export [--format dotenv|json|shell] [--reveal]\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		switch flagEnvFormat {
+		case EnvFormatDotenv, EnvFormatJSON, EnvFormatShell:
+			break
+		default:
+			usage(fmt.Sprintf("invalid format %q", flagEnvFormat))
+		}
+		var vars []*envVar
+		var omitted []string
+		for _, v := range fetchEnv(envSiteURI()) {
+			if v.Secret && !flagReveal {
+				omitted = append(omitted, v.Name)
+				continue
+			}
+			vars = append(vars, v)
+		}
+		if len(omitted) > 0 {
+			warning(fmt.Sprintf("omitting secret variables %s (use --reveal to export them).", strings.Join(omitted, ", ")))
+		}
+		switch flagEnvFormat {
+		case EnvFormatJSON:
+			values := make(map[string]string)
+			for _, v := range vars {
+				values[v.Name] = v.Value
+			}
+			printJSON(values)
+		case EnvFormatShell:
+			for _, v := range vars {
+				fmt.Printf("export %s=%s\n", v.Name, shellQuote(v.Value))
+			}
+		default:
+			for _, v := range vars {
+				fmt.Printf("%s=%s\n", v.Name, dotenvQuote(v.Value))
+			}
+		}
+	},
+}
+
+func envSiteURI() URI {
+	uri, err := LookupSiteURI()
+	if err != nil {
+		fatal(err.Error())
+	}
+	return uri
+}
+
+// envPath returns the API path of the configuration of the site named by uri.
+func envPath(uri URI) string {
+	return sitePath(uri) + "config/"
+}
+
+// fetchEnv returns the configuration variables of a site sorted by name.
+func fetchEnv(uri URI) []*envVar {
+	var vars []*envVar
+	if err := setupAPIClient(uri).List(envPath(uri), &vars); err != nil {
+		if err == kel.ErrNotFound {
+			fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+		}
+		fatal(fmt.Sprintf("failed to get configuration (error: %v)", err))
+	}
+	sort.Sort(envVarsByName(vars))
+	return vars
+}
+
+// applyEnv sends a batch of changes, which the site deploys as one release.
+func applyEnv(uri URI, change *envChange) {
+	var release siteRelease
+	if err := setupAPIClient(uri).Do("PATCH", envPath(uri), "config", change, &release); err != nil {
+		if err == kel.ErrNotFound {
+			fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+		}
+		fatal(fmt.Sprintf("failed to update configuration (error: %v)", err))
+	}
+	n := len(change.Set) + len(change.Unset)
+	success(fmt.Sprintf("updated %d variables of %s/%s (release v%d).", n, uri.ResourceGroup, uri.Site, release.Version))
+}
+
+func newEnvChange(vars map[string]string, secret bool) *envChange {
+	change := &envChange{Set: vars}
+	if secret {
+		for name := range vars {
+			change.Secret = append(change.Secret, name)
+		}
+		sort.Strings(change.Secret)
+	}
+	return change
+}
+
+func (v *envVar) display() string {
+	if v.Secret && !flagReveal {
+		return "********"
+	}
+	return v.Value
+}
+
+// maskEnv returns copies of vars with the values of secret variables masked
+// unless --reveal is given.
+func maskEnv(vars []*envVar) []*envVar {
+	masked := make([]*envVar, len(vars))
+	for i, v := range vars {
+		masked[i] = &envVar{Name: v.Name, Value: v.display(), Secret: v.Secret}
+	}
+	return masked
+}
+
+type envVarsByName []*envVar
+
+func (s envVarsByName) Len() int           { return len(s) }
+func (s envVarsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s envVarsByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// parseDotenv reads NAME=value lines, skipping blank lines and comments. An
+// optional export prefix is allowed and values may be single or double
+// quoted.
+func parseDotenv(r io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !validEnvName(name) {
+			return nil, fmt.Errorf("line %d: expected NAME=value", n)
+		}
+		value := strings.TrimSpace(parts[1])
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value", n)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars[name] = value
+	}
+	return vars, scanner.Err()
+}
+
+func dotenvQuote(value string) string {
+	if strings.ContainsAny(value, " \t\n\"'#$\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
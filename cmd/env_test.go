@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		input string
+		vars  map[string]string
+		err   string
+	}{
+		{"", map[string]string{}, ""},
+		{"FOO=bar", map[string]string{"FOO": "bar"}, ""},
+		{"\n# comment\n  \nFOO=bar\n", map[string]string{"FOO": "bar"}, ""},
+		{"export FOO=bar", map[string]string{"FOO": "bar"}, ""},
+		{" FOO = bar ", map[string]string{"FOO": "bar"}, ""},
+		{"FOO=", map[string]string{"FOO": ""}, ""},
+		{"FOO=a=b", map[string]string{"FOO": "a=b"}, ""},
+		{"FOO=bar # comment", map[string]string{"FOO": "bar"}, ""},
+		{"FOO=bar#baz", map[string]string{"FOO": "bar#baz"}, ""},
+		{`FOO="bar # baz"`, map[string]string{"FOO": "bar # baz"}, ""},
+		{`FOO="a\"b\nc"`, map[string]string{"FOO": "a\"b\nc"}, ""},
+		{`FOO='a\nb'`, map[string]string{"FOO": `a\nb`}, ""},
+		{"FOO=a\nFOO=b", map[string]string{"FOO": "b"}, ""},
+		{"FOO", nil, "line 1: expected NAME=value"},
+		{"FOO=bar\n1FOO=bar", nil, "line 2: expected NAME=value"},
+		{"FOO-BAR=baz", nil, "line 1: expected NAME=value"},
+		{`FOO="\q"`, nil, "line 1: invalid quoted value"},
+	}
+	for _, test := range tests {
+		vars, err := parseDotenv(strings.NewReader(test.input))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("parseDotenv(%q) error = %v, want %q", test.input, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDotenv(%q) error = %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(vars, test.vars) {
+			t.Errorf("parseDotenv(%q) = %v, want %v", test.input, vars, test.vars)
+		}
+	}
+}
+
+func TestDotenvQuoteRoundTrip(t *testing.T) {
+	for _, value := range []string{"", "bar", "a b", "a # b", `a"b`, "a'b", "a\nb", `a\b`, "$HOME"} {
+		vars, err := parseDotenv(strings.NewReader("FOO=" + dotenvQuote(value)))
+		if err != nil {
+			t.Errorf("parseDotenv(dotenvQuote(%q)) error = %v", value, err)
+			continue
+		}
+		if vars["FOO"] != value {
+			t.Errorf("parseDotenv(dotenvQuote(%q)) = %q", value, vars["FOO"])
+		}
+	}
+}
+
+func TestMaskEnv(t *testing.T) {
+	vars := []*envVar{
+		{Name: "DEBUG", Value: "1"},
+		{Name: "TOKEN", Value: "s3cret", Secret: true},
+	}
+	saved := flagReveal
+	defer func() { flagReveal = saved }()
+
+	flagReveal = false
+	masked := maskEnv(vars)
+	if masked[0].Value != "1" || masked[1].Value == "s3cret" {
+		t.Errorf("maskEnv = %v, %v; want secret masked", *masked[0], *masked[1])
+	}
+	if vars[1].Value != "s3cret" {
+		t.Errorf("maskEnv modified its input")
+	}
+
+	flagReveal = true
+	if masked := maskEnv(vars); masked[1].Value != "s3cret" {
+		t.Errorf("maskEnv with --reveal = %q, want %q", masked[1].Value, "s3cret")
+	}
+}
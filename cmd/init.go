@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagInitCluster       string
+	flagInitResourceGroup string
+	flagInitSite          string
+)
+
+func init() {
+	RootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringVarP(&flagInitCluster, "cluster", "", "", "Cluster URI (e.g. //kel.example.com)")
+	initCmd.Flags().StringVarP(&flagInitResourceGroup, "resource-group", "", "", "Resource group to use or create")
+	initCmd.Flags().StringVarP(&flagInitSite, "site", "", "", "Site to use or create")
+	initCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Accept the suggested names without prompting")
+	initCmd.Flags().BoolVarP(&flagForce, "force", "", false, "Replace an existing activation of this directory")
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up a site for this directory",
+	Long: `Pick or create the cluster, resource group and site for this directory,
+activate it and write a starter kel.yaml. Names are suggested from the git
+remote or the directory name.
+
+Without prompting:
+
+  kel init --cluster //kel.example.com --resource-group acme --site web --no-input`,
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel init [--cluster <uri>] [--resource-group <name>] [--site <name>] [--yes]\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			fatal(fmt.Sprintf("failed to get current working directory (%s)", err.Error()))
+		}
+		if siteConfig, ok := config.Sites[cwd]; ok && !flagForce {
+			fatal(fmt.Sprintf("this directory is already activated for %s. Use --force to override.", siteConfig.URI))
+		}
+
+		var defaultCluster string
+		if config.DefaultCluster != nil {
+			defaultCluster = config.DefaultCluster.String()
+		}
+		cluster := ask("Cluster", flagInitCluster, defaultCluster)
+		if !strings.HasPrefix(cluster, "//") {
+			cluster = "//" + cluster
+		}
+		uri, err := ParseURI(cluster)
+		if err != nil {
+			usage(fmt.Sprintf("invalid cluster URI (%v)", err))
+		}
+		uri.ResourceGroup = ""
+		uri.Site = ""
+		if config.DefaultCluster == nil {
+			config.DefaultCluster = &uri
+			config.Save()
+		}
+		kc := setupKelClient(uri)
+
+		suggestedResourceGroup, suggestedSite := suggestSiteNames(cwd)
+		resourceGroups, err := accessibleResourceGroups(setupAPIClient(uri))
+		if err != nil {
+			fatal(fmt.Sprintf("failed to list resource groups (error: %v)", err))
+		}
+		sort.Strings(resourceGroups)
+		if len(resourceGroups) == 1 && flagInitResourceGroup == "" {
+			suggestedResourceGroup = resourceGroups[0]
+		}
+		if len(resourceGroups) > 0 && flagInitResourceGroup == "" && !flagYes && !flagNoInput {
+			fmt.Fprintf(os.Stderr, "Your resource groups: %s\n", strings.Join(resourceGroups, ", "))
+		}
+		uri.ResourceGroup = ask("Resource group", flagInitResourceGroup, suggestedResourceGroup)
+		var resourceGroup kel.ResourceGroup
+		switch err := kc.ResourceGroups.Get(uri.ResourceGroup, &resourceGroup).Do(); err {
+		case nil:
+			break
+		case kel.ErrNotFound:
+			resourceGroup = kel.ResourceGroup{Name: uri.ResourceGroup}
+			if err := kc.ResourceGroups.Create(&resourceGroup).Do(); err != nil {
+				fatal(fmt.Sprintf("failed to create resource group (error: %v)", err))
+			}
+			fmt.Printf("Created resource group %q\n", uri.ResourceGroup)
+		default:
+			fatal(fmt.Sprintf("failed to get resource group (error: %v)", err))
+		}
+
+		uri.Site = ask("Site", flagInitSite, suggestedSite)
+		site := kel.Site{ResourceGroup: &resourceGroup}
+		switch err := kc.Sites.Get(uri.Site, &site).Do(); err {
+		case nil:
+			break
+		case kel.ErrNotFound:
+			site = kel.Site{ResourceGroup: &resourceGroup, Name: uri.Site}
+			if err := kc.Sites.Create(&site).Do(); err != nil {
+				fatal(fmt.Sprintf("failed to create site (error: %v)", err))
+			}
+			fmt.Printf("Created site %q\n", uri.ResourceGroup+"/"+uri.Site)
+		default:
+			fatal(fmt.Sprintf("failed to get site (error: %v)", err))
+		}
+
+		activateSite(uri, cwd)
+		manifestPath := filepath.Join(cwd, ManifestFile)
+		if _, err := os.Stat(manifestPath); err == nil {
+			warning(fmt.Sprintf("%s already exists; it was left unchanged.", ManifestFile))
+		} else {
+			manifest := &Manifest{
+				Cluster:       (&URI{Host: uri.Host, Insecure: uri.Insecure}).String(),
+				ResourceGroup: uri.ResourceGroup,
+				Site:          uri.Site,
+			}
+			if err := writeManifest(manifestPath, manifest); err != nil {
+				fatal(fmt.Sprintf("failed to write %s (error: %v)", ManifestFile, err))
+			}
+			fmt.Printf("Wrote %s\n", ManifestFile)
+		}
+		success(fmt.Sprintf("%s/%s has been activated.", uri.ResourceGroup, uri.Site))
+	},
+}
+
+// ask returns value when given, otherwise prompts with a suggestion. The
+// suggestion is taken without prompting with --yes or --no-input.
+func ask(label, value, suggestion string) string {
+	if value != "" {
+		return value
+	}
+	if suggestion != "" && (flagYes || flagNoInput) {
+		return suggestion
+	}
+	for {
+		question := label + ":"
+		if suggestion != "" {
+			question = fmt.Sprintf("%s [%s]:", label, suggestion)
+		}
+		answer := prompt(question)
+		if answer == "" {
+			answer = suggestion
+		}
+		if answer != "" {
+			return answer
+		}
+	}
+}
+
+// suggestSiteNames suggests a resource group and site from the origin
+// remote of the git repository in dir, falling back to the directory name.
+func suggestSiteNames(dir string) (string, string) {
+	c := exec.Command("git", "config", "--get", "remote.origin.url")
+	c.Dir = dir
+	if out, err := c.Output(); err == nil {
+		if owner, repo := parseGitRemote(strings.TrimSpace(string(out))); repo != "" {
+			return siteName(owner), siteName(repo)
+		}
+	}
+	return "", siteName(filepath.Base(dir))
+}
+
+// parseGitRemote returns the owner and repository of remotes such as
+// git@github.com:owner/repo.git and https://github.com/owner/repo.
+func parseGitRemote(remote string) (string, string) {
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	if i := strings.Index(remote, "://"); i >= 0 {
+		remote = remote[i+3:]
+	} else if i := strings.Index(remote, ":"); i >= 0 {
+		remote = remote[:i] + "/" + remote[i+1:]
+	}
+	parts := strings.Split(remote, "/")
+	if len(parts) < 3 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+// siteName turns a name into a lowercase name of letters, digits and
+// dashes.
+func siteName(name string) string {
+	var b []rune
+	for _, c := range strings.ToLower(name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b = append(b, c)
+		} else if len(b) > 0 && b[len(b)-1] != '-' {
+			b = append(b, '-')
+		}
+	}
+	return strings.TrimRight(string(b), "-")
+}
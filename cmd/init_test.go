@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGitRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		owner  string
+		repo   string
+	}{
+		{"git@github.com:kelproject/kel.git", "kelproject", "kel"},
+		{"git@github.com:kelproject/kel", "kelproject", "kel"},
+		{"https://github.com/kelproject/kel", "kelproject", "kel"},
+		{"https://github.com/kelproject/kel.git", "kelproject", "kel"},
+		{"https://github.com/kelproject/kel/", "kelproject", "kel"},
+		{"ssh://git@gitlab.example.com:2222/group/site.git", "group", "site"},
+		{"https://gitlab.example.com/group/subgroup/site.git", "subgroup", "site"},
+		{"github.com", "", ""},
+		{"https://github.com/kel", "", ""},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		owner, repo := parseGitRemote(test.remote)
+		if owner != test.owner || repo != test.repo {
+			t.Errorf("parseGitRemote(%q) = %q, %q, want %q, %q", test.remote, owner, repo, test.owner, test.repo)
+		}
+	}
+}
+
+func TestSiteName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"kel", "kel"},
+		{"My_Site", "my-site"},
+		{"my.site.com", "my-site-com"},
+		{"--site--", "site"},
+		{"a  b", "a-b"},
+		{"Site 2", "site-2"},
+		{"_", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := siteName(test.name); got != test.want {
+			t.Errorf("siteName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSuggestSiteNamesWithoutRemote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "My_Project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	resourceGroup, site := suggestSiteNames(dir)
+	if want := siteName(filepath.Base(dir)); resourceGroup != "" || site != want {
+		t.Errorf("suggestSiteNames() = %q, %q, want %q, %q", resourceGroup, site, "", want)
+	}
+}
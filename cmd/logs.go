@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+// logsReconnectDelay is the longest wait between reconnects of a followed
+// log stream.
+const logsReconnectDelay = 30 * time.Second
+
+var (
+	flagFollow   bool
+	flagSince    string
+	flagTail     int
+	flagProcess  string
+	flagInstance string
+)
+
+// logRecord is a single line of site output. The API streams them as
+// newline delimited JSON.
+type logRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Process   string    `json:"process_type"`
+	Instance  string    `json:"instance"`
+	Message   string    `json:"message"`
+}
+
+func init() {
+	RootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolVarP(&flagFollow, "follow", "f", false, "Keep streaming new log lines")
+	logsCmd.Flags().StringVarP(&flagSince, "since", "", "", "Only show lines since a duration ago (e.g. 10m) or an RFC 3339 time")
+	logsCmd.Flags().IntVarP(&flagTail, "tail", "", 0, "Only show the last number of lines")
+	logsCmd.Flags().StringVarP(&flagProcess, "process", "p", "", "Only show lines of a process type")
+	logsCmd.Flags().StringVarP(&flagInstance, "instance", "", "", "Only show lines of an instance")
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the logs of the activated site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel logs [-f] [--since <time>] [--tail <n>] [-p <process-type>] [--instance <name>]\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		if flagTail < 0 {
+			usage("--tail must not be negative")
+		}
+		var since time.Time
+		if flagSince != "" {
+			var err error
+			if since, err = parseSince(flagSince, time.Now()); err != nil {
+				usage(err.Error())
+			}
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		api := setupAPIClient(uri)
+		stream := &logStream{since: since}
+		delay := time.Second
+		for {
+			err := stream.read(api, uri)
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+			}
+			if !flagFollow {
+				if err != nil {
+					fatal(fmt.Sprintf("failed to read logs (error: %v)", err))
+				}
+				return
+			}
+			if stream.received {
+				delay = time.Second
+			}
+			if err != nil {
+				warning(fmt.Sprintf("log stream interrupted (%v); reconnecting in %s.", err, delay))
+			}
+			time.Sleep(delay)
+			if delay *= 2; delay > logsReconnectDelay {
+				delay = logsReconnectDelay
+			}
+		}
+	},
+}
+
+// logStream reads log records, resuming from the last seen timestamp when
+// reconnecting.
+type logStream struct {
+	since time.Time
+	// seen holds the records at the last seen timestamp, which are sent
+	// again when resuming from it.
+	seen     map[logRecord]bool
+	resumed  bool
+	received bool
+}
+
+func (s *logStream) read(api *apiClient, uri URI) error {
+	query := url.Values{}
+	if !s.since.IsZero() {
+		query.Set("since", s.since.UTC().Format(time.RFC3339Nano))
+	}
+	if flagTail > 0 && !s.resumed {
+		query.Set("tail", strconv.Itoa(flagTail))
+	}
+	if flagProcess != "" {
+		query.Set("process_type", flagProcess)
+	}
+	if flagInstance != "" {
+		query.Set("instance", flagInstance)
+	}
+	if flagFollow {
+		query.Set("follow", "true")
+	}
+	path := sitePath(uri) + "logs/"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	s.received = false
+	body, err := api.Stream(path)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	s.resumed = true
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("invalid log record: %v", err)
+		}
+		if record.Timestamp.Before(s.since) || s.seen[record] {
+			continue
+		}
+		if !record.Timestamp.Equal(s.since) {
+			s.since = record.Timestamp
+			s.seen = make(map[logRecord]bool)
+		}
+		s.seen[record] = true
+		s.received = true
+		printLogRecord(&record)
+	}
+	return scanner.Err()
+}
+
+func printLogRecord(record *logRecord) {
+	if flagOutput == OutputJSON {
+		buf, err := json.Marshal(record)
+		if err != nil {
+			fatal(fmt.Sprintf("failed to encode log record (error: %v)", err))
+		}
+		fmt.Println(string(buf))
+		return
+	}
+	fmt.Printf("%s %s: %s\n", record.Timestamp.Local().Format(time.RFC3339), green(record.Process+"."+record.Instance), record.Message)
+}
+
+// parseSince parses a duration before now or an RFC 3339 time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("--since must not be negative")
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (must be a duration or RFC 3339 time)", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2016, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"10m", now.Add(-10 * time.Minute), true},
+		{"1h30m", now.Add(-90 * time.Minute), true},
+		{"0s", now, true},
+		{"2016-05-31T08:00:00Z", time.Date(2016, 5, 31, 8, 0, 0, 0, time.UTC), true},
+		{"2016-05-31T08:00:00-06:00", time.Date(2016, 5, 31, 14, 0, 0, 0, time.UTC), true},
+		{"-10m", time.Time{}, false},
+		{"", time.Time{}, false},
+		{"10", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+		{"2016-05-31", time.Time{}, false},
+	}
+	for _, test := range tests {
+		got, err := parseSince(test.value, now)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("parseSince(%q) = %v, want error", test.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSince(%q) error = %v", test.value, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("parseSince(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
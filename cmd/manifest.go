@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/kelproject/kel-go"
+	"gopkg.in/yaml.v2"
+)
+
+// ManifestFile is the default name of the site manifest.
+const ManifestFile = "kel.yaml"
+
+// Manifest describes the desired state of a site. Env vars and domains not
+// listed are left alone unless Prune is set.
+type Manifest struct {
+	Cluster       string            `yaml:"cluster,omitempty"`
+	ResourceGroup string            `yaml:"resource_group"`
+	Site          string            `yaml:"site"`
+	Env           map[string]string `yaml:"env,omitempty"`
+	Scale         map[string]int    `yaml:"scale,omitempty"`
+	Domains       []string          `yaml:"domains,omitempty"`
+	Plugins       map[string]string `yaml:"plugins,omitempty"`
+	Prune         bool              `yaml:"prune,omitempty"`
+}
+
+// manifestChange is a single difference between a manifest and the live
+// state of its site.
+type manifestChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// manifestPlan is the set of changes needed to converge a site.
+type manifestPlan struct {
+	URI     URI
+	Changes []*manifestChange
+	// the live state the plan was computed from
+	resourceGroupExists bool
+	siteExists          bool
+	formation           map[string]int
+	secrets             map[string]bool
+}
+
+// loadManifest reads and validates a manifest file.
+func loadManifest(filename string) (*Manifest, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(buf, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.ResourceGroup == "" || manifest.Site == "" {
+		return nil, fmt.Errorf("resource_group and site are required")
+	}
+	for name := range manifest.Env {
+		if !validEnvName(name) {
+			return nil, fmt.Errorf("invalid env var name %q", name)
+		}
+	}
+	for processType, n := range manifest.Scale {
+		if n < 0 {
+			return nil, fmt.Errorf("invalid instance count %d for %s", n, processType)
+		}
+	}
+	for i := range manifest.Domains {
+		manifest.Domains[i] = strings.ToLower(manifest.Domains[i])
+	}
+	return &manifest, nil
+}
+
+// writeManifest writes a manifest file.
+func writeManifest(filename string, manifest *Manifest) error {
+	buf, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf, 0644)
+}
+
+// URI returns the URI of the site described by the manifest. Without a
+// cluster the default cluster is used.
+func (manifest *Manifest) URI() (URI, error) {
+	var uri URI
+	var err error
+	if manifest.Cluster != "" {
+		uri, err = ParseURI(manifest.Cluster)
+	} else {
+		uri, err = LookupURI()
+	}
+	if err != nil {
+		return URI{}, err
+	}
+	uri.ResourceGroup = manifest.ResourceGroup
+	uri.Site = manifest.Site
+	return uri, nil
+}
+
+// planManifest compares a manifest with the live state of its site.
+func planManifest(manifest *Manifest) (*manifestPlan, error) {
+	uri, err := manifest.URI()
+	if err != nil {
+		return nil, err
+	}
+	plan := &manifestPlan{URI: uri, formation: make(map[string]int), secrets: make(map[string]bool)}
+	kc := setupKelClient(uri)
+	var resourceGroup kel.ResourceGroup
+	switch err := kc.ResourceGroups.Get(uri.ResourceGroup, &resourceGroup).Do(); err {
+	case nil:
+		plan.resourceGroupExists = true
+	case kel.ErrNotFound:
+		plan.add(ChangeCreate, "resource group", uri.ResourceGroup, "", "")
+	default:
+		return nil, fmt.Errorf("failed to get resource group (%v)", err)
+	}
+	if plan.resourceGroupExists {
+		site := kel.Site{ResourceGroup: &resourceGroup}
+		switch err := kc.Sites.Get(uri.Site, &site).Do(); err {
+		case nil:
+			plan.siteExists = true
+		case kel.ErrNotFound:
+			break
+		default:
+			return nil, fmt.Errorf("failed to get site (%v)", err)
+		}
+	}
+	if !plan.siteExists {
+		plan.add(ChangeCreate, "site", uri.ResourceGroup+"/"+uri.Site, "", "")
+	}
+	api := setupAPIClient(uri)
+
+	live := make(map[string]*envVar)
+	if plan.siteExists {
+		for _, v := range fetchEnv(uri) {
+			live[v.Name] = v
+			plan.secrets[v.Name] = v.Secret
+		}
+	}
+	names := make([]string, 0, len(manifest.Env))
+	for name := range manifest.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v, ok := live[name]; !ok {
+			// values only shown with --reveal, as they may be credentials
+			to := (&envVar{Name: name, Value: manifest.Env[name], Secret: true}).display()
+			plan.add(ChangeCreate, "env", name, "", to)
+		} else if v.Value != manifest.Env[name] {
+			to := manifest.Env[name]
+			if v.Secret && !flagReveal {
+				to = v.display()
+			}
+			plan.add(ChangeUpdate, "env", name, v.display(), to)
+		}
+	}
+	if manifest.Prune {
+		for _, name := range sortedEnvNames(live) {
+			if _, ok := manifest.Env[name]; !ok {
+				plan.add(ChangeDelete, "env", name, live[name].display(), "")
+			}
+		}
+	}
+
+	if plan.siteExists {
+		for _, process := range fetchFormation(api, uri) {
+			plan.formation[process.ProcessType] = process.Instances
+		}
+	}
+	for _, processType := range sortedKeys(manifest.Scale) {
+		n := manifest.Scale[processType]
+		if current, ok := plan.formation[processType]; !ok {
+			plan.add(ChangeUpdate, "scale", processType, "-", fmt.Sprintf("%d", n))
+		} else if current != n {
+			plan.add(ChangeUpdate, "scale", processType, fmt.Sprintf("%d", current), fmt.Sprintf("%d", n))
+		}
+	}
+
+	domains := make(map[string]bool)
+	if plan.siteExists {
+		var live []*domainDetails
+		if err := api.List(sitePath(uri)+"domains/", &live); err != nil {
+			return nil, fmt.Errorf("failed to list domains (%v)", err)
+		}
+		for _, domain := range live {
+			domains[domain.Name] = true
+		}
+	}
+	wanted := make(map[string]bool)
+	for _, name := range manifest.Domains {
+		wanted[name] = true
+		if !domains[name] {
+			plan.add(ChangeCreate, "domain", name, "", "")
+		}
+	}
+	if manifest.Prune {
+		var names []string
+		for name := range domains {
+			if !wanted[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			plan.add(ChangeDelete, "domain", name, "", "")
+		}
+	}
+
+	enabled := make(map[string]string)
+	if siteConfig := manifestSiteConfig(uri); siteConfig != nil {
+		enabled = siteConfig.Plugins
+	}
+	for _, name := range sortedPluginNames(manifest.Plugins) {
+		if current, ok := enabled[name]; !ok {
+			plan.add(ChangeCreate, "plugin", name, "", manifest.Plugins[name])
+		} else if current != manifest.Plugins[name] {
+			plan.add(ChangeUpdate, "plugin", name, current, manifest.Plugins[name])
+		}
+	}
+	return plan, nil
+}
+
+func (plan *manifestPlan) add(action, kind, name, from, to string) {
+	plan.Changes = append(plan.Changes, &manifestChange{
+		Action: action,
+		Kind:   kind,
+		Name:   name,
+		From:   from,
+		To:     to,
+	})
+}
+
+// changes returns the changes of the given kind.
+func (plan *manifestPlan) changes(kind string) []*manifestChange {
+	var changes []*manifestChange
+	for _, change := range plan.Changes {
+		if change.Kind == kind {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func (plan *manifestPlan) print() {
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case ChangeCreate:
+			line := fmt.Sprintf("+ %s %s", change.Kind, change.Name)
+			if change.To != "" {
+				line += " = " + change.To
+			}
+			fmt.Println(green(line))
+		case ChangeUpdate:
+			fmt.Println(yellow(fmt.Sprintf("~ %s %s: %s => %s", change.Kind, change.Name, change.From, change.To)))
+		case ChangeDelete:
+			fmt.Println(red(fmt.Sprintf("- %s %s", change.Kind, change.Name)))
+		}
+	}
+}
+
+// manifestSiteConfig returns the activation of the current directory when
+// it is for the site named by uri. Plugins of a manifest are enabled there.
+func manifestSiteConfig(uri URI) *SiteConfig {
+	siteConfig := GetActivatedSiteConfig()
+	if siteConfig == nil || siteConfig.URI == nil || !uri.Equals(*siteConfig.URI) {
+		return nil
+	}
+	return siteConfig
+}
+
+func sortedEnvNames(m map[string]*envVar) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
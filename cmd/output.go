@@ -1,22 +1,48 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/mgutz/ansi"
 )
 
 var (
-	red       = ansi.ColorFunc("red")
-	green     = ansi.ColorFunc("green")
-	whiteBold = ansi.ColorFunc("white+bold")
+	red       = colorFunc("red")
+	green     = colorFunc("green")
+	yellow    = colorFunc("yellow")
+	whiteBold = colorFunc("white+bold")
 )
 
+// colorFunc wraps ansi.ColorFunc so colors are dropped when disabled.
+func colorFunc(style string) func(string) string {
+	color := ansi.ColorFunc(style)
+	return func(s string) string {
+		if !colorEnabled() {
+			return s
+		}
+		return color(s)
+	}
+}
+
+// colorEnabled reports whether output may be colored. Colors are disabled by
+// --no-color or by setting NO_COLOR in the environment.
+func colorEnabled() bool {
+	return !flagNoColor && os.Getenv("NO_COLOR") == ""
+}
+
 func success(s string) {
 	fmt.Fprintf(os.Stdout, "%s %s\n", green("Success:"), s)
 }
 
+func warning(s string) {
+	fmt.Fprintf(os.Stderr, "%s %s\n", yellow("Warning:"), s)
+}
+
 func failure(s string) {
 	fmt.Fprintf(os.Stderr, "%s %s\n", red("Error:"), s)
 }
@@ -25,3 +51,44 @@ func fatal(s string) {
 	failure(s)
 	os.Exit(1)
 }
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) {
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fatal(fmt.Sprintf("failed to encode output (%v)", err.Error()))
+	}
+	fmt.Println(string(buf))
+}
+
+// newTabWriter returns a writer aligning tab separated columns on stdout.
+func newTabWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+}
+
+// stdin is shared by all prompts so that input buffered by one prompt is not
+// lost to the next when stdin is piped.
+var stdin = bufio.NewReader(os.Stdin)
+
+// prompt asks for a line of input on stdin. It fails when prompts are
+// disabled with --no-input.
+func prompt(label string) string {
+	if flagNoInput {
+		fatal(fmt.Sprintf("input required but prompts are disabled (%s)", strings.TrimSpace(strings.TrimSuffix(label, ":"))))
+	}
+	fmt.Fprintf(os.Stderr, "%s ", label)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		fatal(fmt.Sprintf("failed to read input (%v)", err))
+	}
+	return strings.TrimSpace(line)
+}
+
+// confirm asks a yes or no question, defaulting to no.
+func confirm(question string) bool {
+	switch strings.ToLower(prompt(question + " [y/N]")) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
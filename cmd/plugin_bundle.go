@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// A plugin bundle is a gzipped tarball holding manifest.json, a plugin index
+// document of the bundled plugins, and their binaries as sha256/<digest>.
+
+const bundleManifest = "manifest.json"
+
+func init() {
+	pluginsCmd.AddCommand(
+		pluginsBundleCmd,
+		pluginsUnbundleCmd,
+	)
+}
+
+var pluginsBundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Export the plugins of the activated site to a tarball",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins bundle [file]\n")
+			fatal(msg)
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		siteConfig := GetActivatedSiteConfig()
+		if siteConfig == nil {
+			fatal("no site is activated for this directory.")
+		}
+		filename := fmt.Sprintf("%s-%s-plugins.tar.gz", siteConfig.URI.ResourceGroup, siteConfig.URI.Site)
+		if len(args) == 1 {
+			filename = args[0]
+		}
+		var bundle pluginIndex
+		for _, plugin := range resolvePlugins(siteConfig.Plugins, siteConfig) {
+			if plugin.Dev {
+				warning(fmt.Sprintf("skipping linked plugin %q.", plugin.Name))
+				continue
+			}
+			digest, err := fileDigest(plugin.BinaryPath())
+			if err != nil {
+				fatal(fmt.Sprintf("failed to read plugin %q binary (%v)", plugin.Name, err))
+			}
+			bundled := *plugin
+			bundled.SHA256 = digest
+			bundle.Plugins = append(bundle.Plugins, &bundled)
+		}
+		if err := writeBundle(filename, &bundle); err != nil {
+			os.Remove(filename)
+			fatal(fmt.Sprintf("failed to write bundle (%v)", err))
+		}
+		success(fmt.Sprintf("bundled %d plugins into %s.", len(bundle.Plugins), filename))
+	},
+}
+
+var pluginsUnbundleCmd = &cobra.Command{
+	Use:   "unbundle",
+	Short: "Import plugins from a tarball created by kel plugins bundle",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins unbundle <file>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		bundle, err := readBundle(args[0])
+		if err != nil {
+			fatal(fmt.Sprintf("failed to read bundle (%v)", err))
+		}
+		siteConfig := GetActivatedSiteConfig()
+		for _, plugin := range bundle.Plugins {
+			if plugin.Command.BinaryURL != "" {
+				if err := recordCachedURL(plugin.Command.BinaryURL, plugin.SHA256); err != nil {
+					fatal(fmt.Sprintf("failed to update cache (%v)", err))
+				}
+			}
+			if err := plugin.Install(); err != nil {
+				fatal(fmt.Sprintf("failed to install plugin %q (%v)", plugin.Name, err))
+			}
+			config.AddPlugin(plugin)
+			if siteConfig != nil {
+				siteConfig.AddPlugin(plugin)
+			}
+			fmt.Printf("Imported plugin %q (version: %s)\n", plugin.Name, whiteBold(plugin.Version))
+		}
+		config.Save()
+		success(fmt.Sprintf("imported %d plugins from %s.", len(bundle.Plugins), args[0]))
+	},
+}
+
+func writeBundle(filename string, bundle *pluginIndex) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	buf, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: bundleManifest, Mode: 0644, Size: int64(len(buf))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(buf); err != nil {
+		return err
+	}
+	for _, plugin := range bundle.Plugins {
+		if err := addBundleFile(tw, "sha256/"+plugin.SHA256, plugin.BinaryPath()); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func addBundleFile(tw *tar.Writer, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: fi.Size(), ModTime: fi.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// readBundle stores the binaries of a bundle in the download cache and
+// returns its manifest.
+func readBundle(filename string) (*pluginIndex, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gr)
+	var bundle *pluginIndex
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case hdr.Name == bundleManifest:
+			bundle = &pluginIndex{}
+			if err := json.NewDecoder(tr).Decode(bundle); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %v", err)
+			}
+		case strings.HasPrefix(hdr.Name, "sha256/"):
+			digest, err := storeBlob(tr)
+			if err != nil {
+				return nil, err
+			}
+			if hdr.Name != "sha256/"+digest {
+				return nil, fmt.Errorf("checksum mismatch for %s", hdr.Name)
+			}
+		}
+	}
+	if bundle == nil {
+		return nil, fmt.Errorf("missing %s", bundleManifest)
+	}
+	for _, plugin := range bundle.Plugins {
+		if err := checkPublishedPlugin(plugin); err != nil {
+			return nil, err
+		}
+		if plugin.SHA256 == "" {
+			return nil, fmt.Errorf("plugin %s has no checksum", plugin)
+		}
+		if !validDigest(plugin.SHA256) {
+			return nil, fmt.Errorf("plugin %s has an invalid checksum %q", plugin, plugin.SHA256)
+		}
+		if _, err := os.Stat(cacheBlobPath(plugin.SHA256)); err != nil {
+			return nil, fmt.Errorf("plugin %s binary is missing", plugin)
+		}
+	}
+	return bundle, nil
+}
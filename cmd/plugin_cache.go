@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+)
+
+// The download cache stores plugin binaries under ~/.kel/cache/sha256 named
+// by the SHA-256 digest of their content. urls.json maps the URLs binaries
+// were downloaded from to their digests so they can be reused offline.
+
+var cacheIndexMtx sync.Mutex
+
+func getCacheDir() string {
+	return path.Join(getConfigDir(), "cache")
+}
+
+func cacheBlobPath(digest string) string {
+	return path.Join(getCacheDir(), "sha256", digest)
+}
+
+func cacheIndexPath() string {
+	return path.Join(getCacheDir(), "urls.json")
+}
+
+func readCacheIndex() map[string]string {
+	index := make(map[string]string)
+	if buf, err := ioutil.ReadFile(cacheIndexPath()); err == nil {
+		json.Unmarshal(buf, &index)
+	}
+	return index
+}
+
+// cachedDigest returns the digest of the cached download of rawurl.
+func cachedDigest(rawurl string) (string, bool) {
+	cacheIndexMtx.Lock()
+	defer cacheIndexMtx.Unlock()
+	digest, ok := readCacheIndex()[rawurl]
+	return digest, ok
+}
+
+// recordCachedURL remembers that rawurl downloads to the blob digest.
+func recordCachedURL(rawurl, digest string) error {
+	cacheIndexMtx.Lock()
+	defer cacheIndexMtx.Unlock()
+	index := readCacheIndex()
+	index[rawurl] = digest
+	buf, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cacheIndexPath(), buf, 0644)
+}
+
+// fetchCached returns the path of the cached blob for rawurl. If digest is
+// not empty, the content must match it and a cached blob with that digest is
+// used without downloading. Otherwise rawurl is downloaded, since it may serve
+// a newer binary, and the last download of it is used only when that fails.
+func fetchCached(rawurl, digest string) (string, error) {
+	if digest != "" {
+		if !validDigest(digest) {
+			return "", fmt.Errorf("invalid checksum %q for %s", digest, rawurl)
+		}
+		if _, err := os.Stat(cacheBlobPath(digest)); err == nil {
+			return cacheBlobPath(digest), nil
+		}
+	}
+	actual, err := downloadBlob(rawurl)
+	if err != nil {
+		if digest == "" {
+			if cached, ok := cachedDigest(rawurl); ok && validDigest(cached) {
+				if _, statErr := os.Stat(cacheBlobPath(cached)); statErr == nil {
+					warning(fmt.Sprintf("failed to download %s (%v); using the cached copy.", rawurl, err))
+					return cacheBlobPath(cached), nil
+				}
+			}
+		}
+		return "", err
+	}
+	if digest != "" && actual != digest {
+		// the blob is left in place since other URLs may refer to it
+		return "", fmt.Errorf("checksum mismatch for %s (expected sha256 %s, got %s)", rawurl, digest, actual)
+	}
+	if err := recordCachedURL(rawurl, actual); err != nil {
+		return "", err
+	}
+	return cacheBlobPath(actual), nil
+}
+
+// downloadBlob downloads rawurl into the cache and returns its digest.
+func downloadBlob(rawurl string) (string, error) {
+	r, err := openURL(rawurl)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return storeBlob(r)
+}
+
+// validDigest reports whether digest is a hex encoded SHA-256 digest, which
+// makes it safe to use as a file name in the cache.
+func validDigest(digest string) bool {
+	if len(digest) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range digest {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// storeBlob copies r into the cache and returns its digest.
+func storeBlob(r io.Reader) (string, error) {
+	blobDir := path.Dir(cacheBlobPath("x"))
+	if err := os.MkdirAll(blobDir, os.FileMode(0755)); err != nil {
+		return "", fmt.Errorf("unable to create directory %q: %s", blobDir, err.Error())
+	}
+	f, err := ioutil.TempFile(blobDir, "download-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), r); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if err := os.Rename(f.Name(), cacheBlobPath(digest)); err != nil {
+		// another download of the same content may have won the race
+		if _, statErr := os.Stat(cacheBlobPath(digest)); statErr != nil {
+			return "", err
+		}
+	}
+	return digest, nil
+}
+
+// fileDigest returns the SHA-256 digest of the file at p.
+func fileDigest(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile copies src to dst, creating dst with the given mode.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidDigest(t *testing.T) {
+	tests := []struct {
+		digest string
+		ok     bool
+	}{
+		{strings.Repeat("a", 64), true},
+		{"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", true},
+		{"", false},
+		{"../..", false},
+		{strings.Repeat("a", 63), false},
+		{strings.Repeat("a", 65), false},
+		{strings.Repeat("A", 64), false},
+		{strings.Repeat("g", 64), false},
+		{"../" + strings.Repeat("a", 61), false},
+	}
+	for _, test := range tests {
+		if ok := validDigest(test.digest); ok != test.ok {
+			t.Errorf("validDigest(%q) = %v, want %v", test.digest, ok, test.ok)
+		}
+	}
+}
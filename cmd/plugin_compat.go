@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/blang/semver"
+	kelplugin "github.com/kelproject/kel/plugin"
+	"github.com/spf13/cobra"
+)
+
+// CheckCompatible returns an error with an upgrade hint when the plugin
+// manifest declares a kel version range or context protocol this client
+// does not satisfy.
+func (plugin *Plugin) CheckCompatible() error {
+	if plugin.Protocol > kelplugin.ProtocolVersion {
+		return fmt.Errorf("plugin %s requires plugin protocol %d but kel %s only supports protocol %d; upgrade kel to use it", plugin, plugin.Protocol, Version, kelplugin.ProtocolVersion)
+	}
+	if plugin.KelVersion == "" {
+		return nil
+	}
+	vRange, err := semver.ParseRange(plugin.KelVersion)
+	if err != nil {
+		return fmt.Errorf("plugin %s declares an invalid kel version range %q", plugin, plugin.KelVersion)
+	}
+	v, err := semver.Make(Version)
+	if err != nil {
+		// development builds of kel are assumed to be compatible
+		return nil
+	}
+	if !vRange(v) {
+		return fmt.Errorf("plugin %s requires kel %s but this is kel %s; upgrade kel to a version matching %s to use it", plugin, plugin.KelVersion, Version, plugin.KelVersion)
+	}
+	return nil
+}
+
+// incompatibleCmd returns a command standing in for a plugin that cannot
+// run with this kel client.
+func incompatibleCmd(plugin *Plugin, err error) *cobra.Command {
+	return &cobra.Command{
+		Use:     plugin.Command.Use,
+		Aliases: plugin.Command.Aliases,
+		Short:   plugin.Command.Short + " (incompatible)",
+		Run: func(cmd *cobra.Command, args []string) {
+			fatal(err.Error())
+		},
+		DisableFlagParsing: true,
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	kelplugin "github.com/kelproject/kel/plugin"
+)
+
+// newPluginContext builds the context passed to plugin invocations. The URI
+// is taken from --uri, then the activated site and then the default cluster.
+// It fails when --uri cannot be parsed.
+func newPluginContext() (*kelplugin.Context, error) {
+	ctx := &kelplugin.Context{
+		Protocol:   kelplugin.ProtocolVersion,
+		KelVersion: Version,
+		Output:     flagOutput,
+		Color:      colorEnabled(),
+	}
+	uri, ok, err := lookupPluginURI()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return ctx, nil
+	}
+	ctx.URI = uri.String()
+	ctx.ResourceGroup = uri.ResourceGroup
+	ctx.Site = uri.Site
+	ctx.APIURL = apiBaseURL(uri)
+	if config.Auth == AuthCluster {
+		// plugins only receive a token when the user is already logged in;
+		// kel never prompts for credentials on behalf of a plugin.
+		if ts := getClusterTokenSource(); ts != nil {
+			token, err := ts.Token()
+			if err != nil {
+				warning(fmt.Sprintf("unable to refresh access token for plugin (%v)", err))
+			} else {
+				ctx.AccessToken = token.AccessToken
+				ctx.TokenExpiry = token.Expiry
+			}
+		}
+	}
+	return ctx, nil
+}
+
+func lookupPluginURI() (URI, bool, error) {
+	if flagURI != "" {
+		uri, err := ParseURI(flagURI)
+		if err != nil {
+			return URI{}, false, fmt.Errorf("failed to parse --uri %q (error: %v)", flagURI, err)
+		}
+		return uri, true, nil
+	}
+	if siteConfig := GetActivatedSiteConfig(); siteConfig != nil && siteConfig.URI != nil {
+		return *siteConfig.URI, true, nil
+	}
+	if config.DefaultCluster != nil {
+		return *config.DefaultCluster, true, nil
+	}
+	return URI{}, false, nil
+}
+
+// pluginEnviron returns env with the plugin context variables replacing any
+// inherited ones.
+func pluginEnviron(env []string, ctx *kelplugin.Context) []string {
+	overrides := ctx.Environ()
+	keys := make(map[string]bool, len(overrides))
+	for _, kv := range overrides {
+		keys[strings.SplitN(kv, "=", 2)[0]] = true
+	}
+	result := make([]string, 0, len(env)+len(overrides))
+	for _, kv := range env {
+		if !keys[strings.SplitN(kv, "=", 2)[0]] {
+			result = append(result, kv)
+		}
+	}
+	return append(result, overrides...)
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	kelplugin "github.com/kelproject/kel/plugin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const describeTimeout = 5 * time.Second
+
+// describeCache is the on-disk cache of a plugin description. It is only
+// valid for the binary or linked source it was generated from.
+type describeCache struct {
+	ModTime     time.Time              `json:"mod_time"`
+	Size        int64                  `json:"size"`
+	Description *kelplugin.Description `json:"description"`
+}
+
+// describeCachePath returns where the description of the plugin is cached.
+// Linked plugins are cached per source so sites linking different checkouts
+// of a plugin do not share a description.
+func (plugin *Plugin) describeCachePath() string {
+	if plugin.Dev {
+		sum := sha256.Sum256([]byte(plugin.Source))
+		return filepath.Join(getConfigDir(), "plugins", fmt.Sprintf("%s-dev-%x.describe.json", plugin.Name, sum[:6]))
+	}
+	return plugin.BinaryPath() + ".describe.json"
+}
+
+// describeStamp returns the modification time and size identifying the
+// version of the plugin a cached description belongs to. For a linked Go
+// package these are the newest modification time and total size of its Go
+// files, leaving out vendored and hidden directories.
+func (plugin *Plugin) describeStamp() (time.Time, int64, error) {
+	binary := plugin.BinaryPath()
+	if plugin.Dev {
+		binary = plugin.Source
+	}
+	fi, err := os.Stat(binary)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if !fi.IsDir() {
+		return fi.ModTime(), fi.Size(), nil
+	}
+	var modTime time.Time
+	var size int64
+	err = filepath.Walk(plugin.Source, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			name := fi.Name()
+			if p != plugin.Source && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(fi.Name(), ".go") {
+			return nil
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+		size += fi.Size()
+		return nil
+	})
+	return modTime, size, err
+}
+
+// Description returns the self-description of the plugin, or nil when the
+// plugin does not support the describe protocol. Successful descriptions
+// are cached until the plugin binary, or the source of a linked plugin,
+// changes.
+func (plugin *Plugin) Description() *kelplugin.Description {
+	if !plugin.Command.Describe {
+		return nil
+	}
+	modTime, size, err := plugin.describeStamp()
+	if err != nil {
+		return nil
+	}
+	var cache describeCache
+	if buf, err := ioutil.ReadFile(plugin.describeCachePath()); err == nil {
+		if err := json.Unmarshal(buf, &cache); err == nil && cache.ModTime.Equal(modTime) && cache.Size == size {
+			return cache.Description
+		}
+	}
+	description, err := plugin.describe()
+	if err != nil {
+		warning(fmt.Sprintf("plugin %q could not describe itself (%v)", plugin.Name, err))
+		return nil
+	}
+	cache = describeCache{
+		ModTime:     modTime,
+		Size:        size,
+		Description: description,
+	}
+	if buf, err := json.Marshal(&cache); err == nil {
+		os.MkdirAll(filepath.Dir(plugin.describeCachePath()), 0755)
+		ioutil.WriteFile(plugin.describeCachePath(), buf, 0644)
+	}
+	return description
+}
+
+// describe invokes the plugin binary with the describe flag.
+func (plugin *Plugin) describe() (*kelplugin.Description, error) {
+	out, err := plugin.query(kelplugin.DescribeFlag)
+	if err != nil {
+		return nil, err
+	}
+	var description kelplugin.Description
+	if err := json.Unmarshal(out, &description); err != nil {
+		return nil, fmt.Errorf("invalid description: %v", err)
+	}
+	return &description, nil
+}
+
+// query runs the plugin binary with args and returns its output. The
+// plugin is killed if it does not answer within describeTimeout.
+func (plugin *Plugin) query(args ...string) ([]byte, error) {
+	binary, prefix, err := plugin.Executable()
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	c := exec.Command(binary, append(prefix, args...)...)
+	c.Env = pluginEnviron(os.Environ(), &kelplugin.Context{
+		Protocol:   kelplugin.ProtocolVersion,
+		KelVersion: Version,
+	})
+	c.Stdout = &out
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Wait()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+	case <-time.After(describeTimeout):
+		c.Process.Kill()
+		return nil, fmt.Errorf("timed out after %s", describeTimeout)
+	}
+	return out.Bytes(), nil
+}
+
+// describeCmd fills cmd from a plugin description, adding flags and
+// subcommands. Every command in the tree runs the plugin with the
+// subcommand path prepended to its arguments.
+func (plugin *Plugin) describeCmd(cmd *cobra.Command, description *kelplugin.Description, cmdPath []string) {
+	if description.Short != "" {
+		cmd.Short = description.Short
+	}
+	cmd.Long = description.Long
+	cmd.Example = description.Example
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		os.Exit(plugin.Run(append(cmdPath, args...)))
+	}
+	for _, flag := range description.Flags {
+		if err := checkDescribedFlag(cmd, flag); err != nil {
+			warning(fmt.Sprintf("plugin %q: ignoring flag of %s (%v)", plugin.Name, cmd.Name(), err))
+			continue
+		}
+		switch flag.Type {
+		case "bool":
+			cmd.Flags().BoolP(flag.Name, flag.Shorthand, flag.Default == "true", flag.Usage)
+		default:
+			cmd.Flags().StringP(flag.Name, flag.Shorthand, flag.Default, flag.Usage)
+		}
+		if flag.Complete {
+			cmd.Flags().SetAnnotation(flag.Name, cobra.BashCompCustom, []string{"__kel_plugin_complete"})
+		}
+	}
+	for _, sub := range description.Commands {
+		fields := strings.Fields(sub.Use)
+		if len(fields) == 0 {
+			continue
+		}
+		subCmd := &cobra.Command{
+			Use:     sub.Use,
+			Aliases: sub.Aliases,
+		}
+		subPath := append(append([]string{}, cmdPath...), fields[0])
+		plugin.describeCmd(subCmd, sub, subPath)
+		cmd.AddCommand(subCmd)
+	}
+}
+
+// checkDescribedFlag returns an error if flag cannot be added to cmd, which
+// would make pflag panic: an invalid name or shorthand, or one already used
+// by cmd, kel's global flags or help.
+func checkDescribedFlag(cmd *cobra.Command, flag *kelplugin.FlagDescription) error {
+	if flag.Name == "" || flag.Name == "help" || strings.HasPrefix(flag.Name, "-") || strings.ContainsAny(flag.Name, " \t=") {
+		return fmt.Errorf("invalid name %q", flag.Name)
+	}
+	if len(flag.Shorthand) > 1 || flag.Shorthand == "h" || flag.Shorthand == "-" || flag.Shorthand == "=" {
+		return fmt.Errorf("invalid shorthand %q for --%s", flag.Shorthand, flag.Name)
+	}
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags(), RootCmd.PersistentFlags()} {
+		if flags.Lookup(flag.Name) != nil {
+			return fmt.Errorf("--%s is already defined", flag.Name)
+		}
+		if flag.Shorthand != "" && lookupShorthand(flags, flag.Shorthand) != nil {
+			return fmt.Errorf("-%s is already defined", flag.Shorthand)
+		}
+	}
+	return nil
+}
+
+// helpRequested reports whether args ask for help before any "--".
+func helpRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "-h", "--help":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kelplugin "github.com/kelproject/kel/plugin"
+	"github.com/spf13/cobra"
+)
+
+func TestDescribeCmdSkipsInvalidFlags(t *testing.T) {
+	plugin := &Plugin{Name: "kel-build", Version: "1.0.0"}
+	cmd := &cobra.Command{Use: "build"}
+	description := &kelplugin.Description{
+		Flags: []*kelplugin.FlagDescription{
+			{Name: "tag", Shorthand: "t"},
+			{Name: "push", Type: "bool"},
+			{Name: "tag"},
+			{Name: "target", Shorthand: "t"},
+			{Name: "long", Shorthand: "ll"},
+			{Name: "help"},
+			{Name: "hidden", Shorthand: "h"},
+			{Name: "output"},
+			{Name: "format", Shorthand: "o"},
+			{Name: ""},
+			{Name: "bad name"},
+		},
+	}
+	plugin.describeCmd(cmd, description, nil)
+	for _, name := range []string{"tag", "push"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag --%s was not added", name)
+		}
+	}
+	for _, name := range []string{"target", "long", "hidden", "format"} {
+		if cmd.Flags().Lookup(name) != nil {
+			t.Errorf("invalid flag --%s was added", name)
+		}
+	}
+	if flag := cmd.Flags().Lookup("tag"); flag == nil || flag.Shorthand != "t" {
+		t.Errorf("duplicate --tag replaced the first definition")
+	}
+}
+
+func TestDescribeStampLinkedSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kel-describe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string, modTime time.Time) {
+		p := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(p, modTime, modTime)
+	}
+	base := time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC)
+	write("main.go", base)
+	plugin := &Plugin{Name: "kel-build", Dev: true, Source: dir}
+	modTime, size, err := plugin.describeStamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modTime.Equal(base) {
+		t.Errorf("describeStamp() time = %v, want %v", modTime, base)
+	}
+
+	later := base.Add(time.Hour)
+	write("README.md", later)
+	write("vendor/dep/dep.go", later)
+	write(".git/x.go", later)
+	if m, s, _ := plugin.describeStamp(); !m.Equal(modTime) || s != size {
+		t.Errorf("describeStamp() changed for files that are not build inputs")
+	}
+
+	write("cmd/flags.go", later)
+	if m, _, _ := plugin.describeStamp(); !m.Equal(later) {
+		t.Errorf("describeStamp() time = %v, want %v", m, later)
+	}
+}
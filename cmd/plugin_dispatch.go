@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// CommandName returns the name of the command provided by the plugin.
+func (plugin *Plugin) CommandName() string {
+	fields := strings.Fields(plugin.Command.Use)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// HasCommand reports whether name is the plugin command name or one of its
+// aliases. Names must match exactly.
+func (plugin *Plugin) HasCommand(name string) bool {
+	if name == "" {
+		return false
+	}
+	if name == plugin.CommandName() {
+		return true
+	}
+	for _, alias := range plugin.Command.Aliases {
+		if name == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// routePluginArgs finds the command named in args after any of kel's
+// persistent flags. If it belongs to one of plugins, the returned args have
+// "--" inserted after the command name so that everything following it
+// reaches the plugin untouched. Otherwise args are returned as given.
+func routePluginArgs(args []string, flags *pflag.FlagSet, plugins []*Plugin) ([]string, *Plugin) {
+	i, plugin := findPluginCommand(args, flags, plugins)
+	if plugin == nil {
+		return args, nil
+	}
+	routed := make([]string, 0, len(args)+1)
+	routed = append(routed, args[:i+1]...)
+	routed = append(routed, "--")
+	return append(routed, args[i+1:]...), plugin
+}
+
+// findPluginCommand returns the index in args of the command name following
+// kel's persistent flags along with the plugin providing it, if any.
+func findPluginCommand(args []string, flags *pflag.FlagSet, plugins []*Plugin) (int, *Plugin) {
+	i := 0
+	for i < len(args) {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+		var flag *pflag.Flag
+		hasValue := false
+		if strings.HasPrefix(arg, "--") {
+			name := arg[2:]
+			if n := strings.Index(name, "="); n >= 0 {
+				name = name[:n]
+				hasValue = true
+			}
+			flag = flags.Lookup(name)
+		} else {
+			flag = lookupShorthand(flags, arg[1:2])
+			hasValue = len(arg) > 2
+		}
+		if flag == nil {
+			// leave unknown flags for cobra to report
+			return -1, nil
+		}
+		i++
+		if !hasValue && flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+	if i >= len(args) || args[i] == "--" {
+		return -1, nil
+	}
+	for _, plugin := range plugins {
+		if plugin.HasCommand(args[i]) {
+			return i, plugin
+		}
+	}
+	return -1, nil
+}
+
+// lookupShorthand returns the flag with the given shorthand letter, or nil.
+func lookupShorthand(flags *pflag.FlagSet, shorthand string) *pflag.Flag {
+	var found *pflag.Flag
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Shorthand == shorthand {
+			found = flag
+		}
+	})
+	return found
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func testPersistentFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("kel", pflag.ContinueOnError)
+	flags.StringP("uri", "", "", "")
+	flags.StringP("output", "o", "text", "")
+	flags.BoolP("no-color", "", false, "")
+	return flags
+}
+
+func TestRoutePluginArgs(t *testing.T) {
+	plugins := []*Plugin{
+		{Name: "kel-build", Command: PluginCommand{Use: "build [flags]", Aliases: []string{"b"}}},
+		{Name: "kel-deploy", Command: PluginCommand{Use: "deploy"}},
+	}
+	tests := []struct {
+		name   string
+		args   []string
+		routed []string
+		plugin string
+	}{
+		{"plugin command", []string{"build", "--fast"}, []string{"build", "--", "--fast"}, "kel-build"},
+		{"alias", []string{"b", "-x"}, []string{"b", "--", "-x"}, "kel-build"},
+		{"no plugin arguments", []string{"deploy"}, []string{"deploy", "--"}, "kel-deploy"},
+		{"flag with separate value", []string{"--uri", "//host", "build", "-v"}, []string{"--uri", "//host", "build", "--", "-v"}, "kel-build"},
+		{"flag with inline value", []string{"--uri=//host", "build"}, []string{"--uri=//host", "build", "--"}, "kel-build"},
+		{"shorthand with separate value", []string{"-o", "json", "build"}, []string{"-o", "json", "build", "--"}, "kel-build"},
+		{"shorthand with attached value", []string{"-ojson", "build"}, []string{"-ojson", "build", "--"}, "kel-build"},
+		{"boolean flag", []string{"--no-color", "build", "x"}, []string{"--no-color", "build", "--", "x"}, "kel-build"},
+		{"plugin name as flag value", []string{"-o", "build", "sites"}, []string{"-o", "build", "sites"}, ""},
+		{"builtin command", []string{"sites", "build"}, []string{"sites", "build"}, ""},
+		{"unknown flag", []string{"--bogus", "build"}, []string{"--bogus", "build"}, ""},
+		{"double dash", []string{"--", "build"}, []string{"--", "build"}, ""},
+		{"prefix of command name", []string{"buil"}, []string{"buil"}, ""},
+		{"no arguments", []string{}, []string{}, ""},
+	}
+	for _, test := range tests {
+		routed, plugin := routePluginArgs(test.args, testPersistentFlags(), plugins)
+		if !reflect.DeepEqual(routed, test.routed) {
+			t.Errorf("%s: routed args = %q, want %q", test.name, routed, test.routed)
+		}
+		name := ""
+		if plugin != nil {
+			name = plugin.Name
+		}
+		if name != test.plugin {
+			t.Errorf("%s: plugin = %q, want %q", test.name, name, test.plugin)
+		}
+	}
+}
+
+func TestFindPluginCommandIndex(t *testing.T) {
+	plugins := []*Plugin{{Name: "kel-build", Command: PluginCommand{Use: "build"}}}
+	tests := []struct {
+		args  []string
+		index int
+	}{
+		{[]string{"build"}, 0},
+		{[]string{"--uri", "//host", "build"}, 2},
+		{[]string{"-o", "json", "--no-color", "build"}, 3},
+		{[]string{"--uri"}, -1},
+		{[]string{"-", "build"}, -1},
+	}
+	for _, test := range tests {
+		if i, _ := findPluginCommand(test.args, testPersistentFlags(), plugins); i != test.index {
+			t.Errorf("findPluginCommand(%q) = %d, want %d", test.args, i, test.index)
+		}
+	}
+}
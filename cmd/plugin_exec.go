@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+)
+
+const (
+	PluginExecSubprocess = "subprocess"
+	PluginExecReplace    = "exec"
+)
+
+// Run will run the plugin binary with the given arguments and the plugin
+// context in its environment. It returns the exit code of the plugin. In
+// exec mode the kel process is replaced and Run only returns on failure.
+func (plugin *Plugin) Run(args []string) int {
+	binary, prefix, err := plugin.Executable()
+	if err != nil {
+		fatal(fmt.Sprintf("failed running plugin %q: %s", plugin.Name, err.Error()))
+	}
+	args = append(prefix, args...)
+	ctx, err := newPluginContext()
+	if err != nil {
+		fatal(err.Error())
+	}
+	env := pluginEnviron(os.Environ(), ctx)
+	if config.PluginExec == PluginExecReplace {
+		argv := append([]string{path.Base(binary)}, args...)
+		if err := execPlugin(binary, argv, env); err != nil {
+			fatal(fmt.Sprintf("failed executing plugin %q binary %s: %s", plugin.Name, binary, err.Error()))
+		}
+	}
+	code, err := runPluginProcess(binary, args, env)
+	if err != nil {
+		fatal(fmt.Sprintf("failed running plugin %q binary %s: %s", plugin.Name, binary, err.Error()))
+	}
+	return code
+}
+
+// runPluginProcess runs binary as a child process sharing kel's stdio.
+// Signals sent to kel are forwarded to the child, except interrupts typed
+// at the terminal kel runs in the foreground of, which the terminal already
+// sends to the child. It returns the exit code of the child.
+func runPluginProcess(binary string, args []string, env []string) (int, error) {
+	c := exec.Command(binary, args...)
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	// handled rather than ignored, as ignored signals stay ignored in the child
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, interruptSignals...)
+	defer signal.Stop(interrupts)
+	signals := make(chan os.Signal, 1)
+	if len(forwardedSignals) > 0 {
+		signal.Notify(signals, forwardedSignals...)
+		defer signal.Stop(signals)
+	}
+	if err := c.Start(); err != nil {
+		return -1, err
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				c.Process.Signal(sig)
+			case sig := <-interrupts:
+				if !terminalForeground() {
+					c.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := c.Wait()
+	close(done)
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return -1, err
+		}
+	}
+	return exitStatus(c.ProcessState), nil
+}
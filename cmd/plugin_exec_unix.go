@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// interruptSignals are sent by the terminal to its whole foreground process
+// group, so a child process in that group receives them without kel
+// forwarding them.
+var interruptSignals = []os.Signal{os.Interrupt, syscall.SIGQUIT}
+
+// forwardedSignals are sent to kel alone and are passed on to the child.
+var forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// terminalForeground reports whether kel, and the children sharing its
+// process group, are the foreground process group of the terminal on
+// stdin.
+func terminalForeground() bool {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), syscall.TIOCGPGRP, uintptr(unsafe.Pointer(&pgrp)))
+	return errno == 0 && int(pgrp) == syscall.Getpgrp()
+}
+
+// execPlugin replaces the kel process with the plugin binary.
+func execPlugin(binary string, argv []string, env []string) error {
+	return syscall.Exec(binary, argv, env)
+}
+
+// exitStatus returns the exit code of a finished process. A process killed
+// by a signal is reported the way shells do, as 128 plus the signal number.
+func exitStatus(state *os.ProcessState) int {
+	status := state.Sys().(syscall.WaitStatus)
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return status.ExitStatus()
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Windows delivers console interrupts to every process attached to the
+// console, so the child receives them directly. kel only needs to keep them
+// from terminating itself before the child exits.
+var interruptSignals = []os.Signal{os.Interrupt}
+
+var forwardedSignals []os.Signal
+
+// terminalForeground reports whether console interrupts reach the child
+// directly, which is always the case on Windows.
+func terminalForeground() bool {
+	return true
+}
+
+// execPlugin is not supported on Windows which has no exec system call.
+func execPlugin(binary string, argv []string, env []string) error {
+	return errors.New("exec mode is not supported on windows (use kel config set plugin-exec subprocess)")
+}
+
+// exitStatus returns the exit code of a finished process.
+func exitStatus(state *os.ProcessState) int {
+	return state.Sys().(syscall.WaitStatus).ExitStatus()
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	kelplugin "github.com/kelproject/kel/plugin"
+)
+
+// HandlesHook reports whether the plugin manifest declares the event.
+func (plugin *Plugin) HandlesHook(event string) bool {
+	for _, hook := range plugin.Hooks {
+		if hook == event {
+			return true
+		}
+	}
+	return false
+}
+
+// hookTimeout is how long a hook may run before it is killed.
+const hookTimeout = 30 * time.Second
+
+// runHooks runs the hook for event in every global plugin and plugin of
+// siteConfig declaring it. Hook failures are reported as warnings; they
+// never stop kel or touch the configuration.
+func runHooks(event string, siteConfig *SiteConfig, extraEnv ...string) {
+	for _, plugin := range resolvePlugins(effectivePluginRanges(siteConfig), siteConfig) {
+		if !plugin.HandlesHook(event) {
+			continue
+		}
+		if err := plugin.runHook(event, extraEnv); err != nil {
+			warning(fmt.Sprintf("plugin %q %s hook failed (%v)", plugin.Name, event, err))
+		}
+	}
+}
+
+func (plugin *Plugin) runHook(event string, extraEnv []string) error {
+	binary, prefix, err := plugin.Executable()
+	if err != nil {
+		return err
+	}
+	ctx, err := newPluginContext()
+	if err != nil {
+		return err
+	}
+	c := exec.Command(binary, append(prefix, kelplugin.HookFlag, event)...)
+	env := pluginEnviron(os.Environ(), ctx)
+	env = append(env, kelplugin.EnvHook+"="+event)
+	c.Env = append(env, extraEnv...)
+	// stdin is left for the command itself
+	c.Stdin = nil
+	// keep hook output apart from the output of the command itself
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	if err := c.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(hookTimeout):
+		c.Process.Kill()
+		<-done
+		return fmt.Errorf("timed out after %s", hookTimeout)
+	}
+}
+
+// resolvePlugins returns the plugins selected by a map of plugin version
+// ranges and the plugins linked in siteConfig, skipping any that cannot be
+// resolved.
+func resolvePlugins(ranges map[string]string, siteConfig *SiteConfig) []*Plugin {
+	var plugins []*Plugin
+	for _, pluginName := range sortedPluginNames(ranges) {
+		resolution, err := resolveSitePlugin(pluginName, ranges[pluginName], siteConfig)
+		if err != nil || resolution.Plugin == nil {
+			continue
+		}
+		plugins = append(plugins, resolution.Plugin)
+	}
+	return plugins
+}
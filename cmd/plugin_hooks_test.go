@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRunHookInvalidURI(t *testing.T) {
+	saved := flagURI
+	defer func() { flagURI = saved }()
+	flagURI = "not-a-uri"
+	plugin := &Plugin{Name: "kel-hook", Version: DevVersion, Dev: true, Source: os.Args[0]}
+	if err := plugin.runHook("pre-deploy", nil); err == nil {
+		t.Errorf("runHook with an invalid --uri = nil, want error")
+	}
+}
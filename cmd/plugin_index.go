@@ -0,0 +1,407 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/spf13/cobra"
+)
+
+// pluginIndex is the document served by a plugin index. Directory indexes
+// hold one plugin manifest per JSON file instead.
+type pluginIndex struct {
+	Plugins []*Plugin `json:"plugins"`
+}
+
+func init() {
+	pluginsCmd.AddCommand(
+		pluginsIndexCmd,
+		pluginsSearchCmd,
+		pluginsInstallCmd,
+	)
+	pluginsIndexCmd.AddCommand(
+		pluginsIndexAddCmd,
+		pluginsIndexRemoveCmd,
+		pluginsIndexListCmd,
+	)
+	pluginsInstallCmd.Flags().BoolVarP(&flagGlobal, "global", "", false, "Enable the plugin in every directory")
+}
+
+var pluginsIndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage plugin indexes",
+}
+
+var pluginsIndexAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a plugin index",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins index add <url>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		u, err := url.Parse(args[0])
+		if err != nil {
+			fatal(fmt.Sprintf("failed to parse index URL (error: %v)", err))
+		}
+		switch u.Scheme {
+		case "http", "https", "file":
+			break
+		default:
+			fatal("index URL must use http, https or file")
+		}
+		for _, index := range config.PluginIndexes {
+			if index == args[0] {
+				fatal(fmt.Sprintf("index %s is already configured.", args[0]))
+			}
+		}
+		if _, err := fetchPluginIndex(args[0]); err != nil {
+			fatal(fmt.Sprintf("failed to read index %s (error: %v)", args[0], err))
+		}
+		config.PluginIndexes = append(config.PluginIndexes, args[0])
+		config.Save()
+		success(fmt.Sprintf("added plugin index %s.", args[0]))
+	},
+}
+
+var pluginsIndexRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a plugin index",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins index remove <url>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		for i, index := range config.PluginIndexes {
+			if index == args[0] {
+				config.PluginIndexes = append(config.PluginIndexes[:i], config.PluginIndexes[i+1:]...)
+				config.Save()
+				success(fmt.Sprintf("removed plugin index %s.", args[0]))
+				return
+			}
+		}
+		fatal(fmt.Sprintf("index %s is not configured.", args[0]))
+	},
+}
+
+var pluginsIndexListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List plugin indexes",
+	Run: func(cmd *cobra.Command, args []string) {
+		if flagOutput == OutputJSON {
+			printJSON(config.PluginIndexes)
+			return
+		}
+		for _, index := range config.PluginIndexes {
+			fmt.Println(index)
+		}
+	},
+}
+
+var pluginsSearchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search plugin indexes",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins search [term]\n")
+			fatal(msg)
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		if len(config.PluginIndexes) == 0 {
+			fatal("no plugin indexes configured (add one with kel plugins index add).")
+		}
+		var term string
+		if len(args) == 1 {
+			term = strings.ToLower(args[0])
+		}
+		byName := make(map[string]pluginsByVersion)
+		for _, plugin := range indexPlugins() {
+			if term != "" && !strings.Contains(strings.ToLower(plugin.Name), term) && !strings.Contains(strings.ToLower(plugin.Command.Short), term) {
+				continue
+			}
+			byName[plugin.Name] = append(byName[plugin.Name], plugin)
+		}
+		names := make([]string, 0, len(byName))
+		for name := range byName {
+			names = append(names, name)
+			sort.Sort(byName[name])
+		}
+		sort.Strings(names)
+		if flagOutput == OutputJSON {
+			var plugins []*Plugin
+			for _, name := range names {
+				plugins = append(plugins, byName[name]...)
+			}
+			printJSON(plugins)
+			return
+		}
+		w := newTabWriter()
+		fmt.Fprintf(w, "NAME\tLATEST\tVERSIONS\tDESCRIPTION\n")
+		for _, name := range names {
+			versions := make([]string, len(byName[name]))
+			for i, plugin := range byName[name] {
+				versions[i] = plugin.Version
+			}
+			latest := byName[name][0]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, latest.Version, strings.Join(versions, ", "), latest.Command.Short)
+		}
+		w.Flush()
+	},
+}
+
+var pluginsInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a plugin from the plugin indexes",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins install <name> [version-range]\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 2 {
+			usage("too many arguments")
+		}
+		name := args[0]
+		versionRange := ">=0.0.0"
+		if len(args) == 2 {
+			versionRange = args[1]
+		}
+		plugin, err := resolveIndexPlugin(name, versionRange)
+		if err != nil {
+			fatal(err.Error())
+		}
+		if _, ok := config.Plugins[plugin.String()]; !ok {
+			fmt.Printf("Installing plugin %q... ", plugin.Name)
+			if err := plugin.Install(); err != nil {
+				fmt.Printf("%s\n", red("error"))
+				fatal(err.Error())
+			}
+			config.AddPlugin(plugin)
+			fmt.Printf("%s (version: %s)\n", green("installed"), whiteBold(plugin.Version))
+		}
+		enabledRange := fmt.Sprintf("=%s", plugin.Version)
+		if len(args) == 2 {
+			enabledRange = versionRange
+		}
+		if flagGlobal {
+			config.EnableGlobalPlugin(plugin.Name, enabledRange)
+		} else if siteConfig := GetActivatedSiteConfig(); siteConfig != nil {
+			siteConfig.EnablePlugin(plugin.Name, enabledRange)
+		}
+		config.Save()
+		success(fmt.Sprintf("%s is installed.", plugin))
+	},
+}
+
+// resolveIndexPlugin returns the highest version of the named plugin across
+// all configured indexes satisfying versionRange and compatible with this
+// kel client.
+func resolveIndexPlugin(name, versionRange string) (*Plugin, error) {
+	vRange, err := semver.ParseRange(versionRange)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %q version range %q is invalid", name, versionRange)
+	}
+	if len(config.PluginIndexes) == 0 {
+		return nil, fmt.Errorf("no plugin indexes configured (add one with kel plugins index add)")
+	}
+	var candidates pluginsByVersion
+	for _, plugin := range indexPlugins() {
+		if plugin.Name == name {
+			candidates = append(candidates, plugin)
+		}
+	}
+	sort.Sort(candidates)
+	var incompatible error
+	for _, plugin := range candidates {
+		if v, err := semver.Make(plugin.Version); err != nil || !vRange(v) {
+			continue
+		}
+		if err := plugin.CheckCompatible(); err != nil {
+			if incompatible == nil {
+				incompatible = err
+			}
+			continue
+		}
+		return plugin, nil
+	}
+	if incompatible != nil {
+		return nil, incompatible
+	}
+	return nil, fmt.Errorf("no plugin matching %s %s was found in the plugin indexes", name, versionRange)
+}
+
+// indexPlugins returns the plugins of all configured indexes. When several
+// indexes provide the same version of a plugin, the first index wins.
+// Unreadable indexes are skipped with a warning.
+func indexPlugins() []*Plugin {
+	var plugins []*Plugin
+	seen := make(map[string]bool)
+	for _, index := range config.PluginIndexes {
+		entries, err := fetchPluginIndex(index)
+		if err != nil {
+			warning(fmt.Sprintf("skipping plugin index %s (%v)", index, err))
+			continue
+		}
+		for _, plugin := range entries {
+			if seen[plugin.String()] {
+				continue
+			}
+			seen[plugin.String()] = true
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+// fetchPluginIndex reads the plugins of an index. An index is either an
+// HTTP(S) or file:// URL of an index document, or a file:// URL of a
+// directory of plugin manifests. Relative binary URLs are resolved against
+// the index location.
+func fetchPluginIndex(index string) ([]*Plugin, error) {
+	base, err := url.Parse(index)
+	if err != nil {
+		return nil, err
+	}
+	var plugins []*Plugin
+	if base.Scheme == "file" {
+		dir := fileURLPath(base)
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			if !strings.HasSuffix(base.Path, "/") {
+				base.Path += "/"
+			}
+			matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range matches {
+				buf, err := ioutil.ReadFile(match)
+				if err != nil {
+					return nil, err
+				}
+				var plugin Plugin
+				if err := json.Unmarshal(buf, &plugin); err != nil {
+					return nil, fmt.Errorf("invalid manifest %s: %v", match, err)
+				}
+				plugins = append(plugins, &plugin)
+			}
+			return resolveBinaryURLs(base, plugins)
+		}
+	}
+	r, err := openURL(index)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var doc pluginIndex
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid index: %v", err)
+	}
+	return resolveBinaryURLs(base, doc.Plugins)
+}
+
+func resolveBinaryURLs(base *url.URL, plugins []*Plugin) ([]*Plugin, error) {
+	for _, plugin := range plugins {
+		if plugin.Name == "" || plugin.Version == "" {
+			return nil, fmt.Errorf("plugin manifest without name or version")
+		}
+		if err := checkPublishedPlugin(plugin); err != nil {
+			return nil, err
+		}
+		ref, err := url.Parse(plugin.Command.BinaryURL)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s has an invalid binary URL: %v", plugin, err)
+		}
+		plugin.Command.BinaryURL = base.ResolveReference(ref).String()
+	}
+	return plugins, nil
+}
+
+// checkPublishedPlugin rejects manifests from indexes and bundles that claim
+// to be linked plugins, which would make kel run a local path instead of
+// the published binary. The name and version end up in the path of the
+// installed binary, so they must be a plain name and a semver version.
+func checkPublishedPlugin(plugin *Plugin) error {
+	if !validPluginName(plugin.Name) {
+		return fmt.Errorf("plugin manifest has an invalid name %q", plugin.Name)
+	}
+	if _, err := semver.Make(plugin.Version); err != nil {
+		return fmt.Errorf("plugin %q has an invalid version %q", plugin.Name, plugin.Version)
+	}
+	if plugin.Dev || plugin.Source != "" {
+		return fmt.Errorf("plugin %s is marked as a linked plugin and cannot be installed", plugin)
+	}
+	return nil
+}
+
+// validPluginName reports whether name is made of lowercase letters, digits,
+// dots, dashes and underscores and starts with a letter or digit.
+func validPluginName(name string) bool {
+	if name == "" || strings.Contains(name, "..") {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case i > 0 && (c == '.' || c == '_' || c == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// openURL opens an http, https or file URL for reading.
+func openURL(rawurl string) (io.ReadCloser, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "file" {
+		return os.Open(fileURLPath(u))
+	}
+	resp, err := http.Get(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", rawurl, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// fileURLPath returns the local path of a file:// URL.
+func fileURLPath(u *url.URL) string {
+	p := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/dir has the path /C:/dir
+		p = strings.TrimPrefix(p, "/")
+	}
+	return filepath.FromSlash(p)
+}
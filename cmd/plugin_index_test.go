@@ -0,0 +1,34 @@
+package cmd
+
+import "testing"
+
+func TestCheckPublishedPlugin(t *testing.T) {
+	tests := []struct {
+		plugin *Plugin
+		ok     bool
+	}{
+		{&Plugin{Name: "kel-build", Version: "1.0.0"}, true},
+		{&Plugin{Name: "kel_build.v2", Version: "1.0.0-beta.1+abc"}, true},
+		{&Plugin{Name: "", Version: "1.0.0"}, false},
+		{&Plugin{Name: "../../bin/x", Version: "1.0.0"}, false},
+		{&Plugin{Name: "kel/build", Version: "1.0.0"}, false},
+		{&Plugin{Name: "kel..build", Version: "1.0.0"}, false},
+		{&Plugin{Name: ".kel", Version: "1.0.0"}, false},
+		{&Plugin{Name: "-kel", Version: "1.0.0"}, false},
+		{&Plugin{Name: "Kel", Version: "1.0.0"}, false},
+		{&Plugin{Name: "kel-build", Version: ""}, false},
+		{&Plugin{Name: "kel-build", Version: "latest"}, false},
+		{&Plugin{Name: "kel-build", Version: "1.0.0/../../x"}, false},
+		{&Plugin{Name: "kel-build", Version: "1.0.0", Dev: true}, false},
+		{&Plugin{Name: "kel-build", Version: "1.0.0", Source: "/tmp/kel-build"}, false},
+	}
+	for _, test := range tests {
+		err := checkPublishedPlugin(test.plugin)
+		if test.ok && err != nil {
+			t.Errorf("checkPublishedPlugin(%q, %q) = %v, want nil", test.plugin.Name, test.plugin.Version, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("checkPublishedPlugin(%q, %q) = nil, want error", test.plugin.Name, test.plugin.Version)
+		}
+	}
+}
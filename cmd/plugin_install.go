@@ -0,0 +1,41 @@
+package cmd
+
+import "sync"
+
+// pluginInstallWorkers bounds the number of concurrent plugin downloads.
+const pluginInstallWorkers = 4
+
+// pluginInstallResult is the outcome of installing a single plugin.
+type pluginInstallResult struct {
+	Plugin *Plugin
+	Err    error
+}
+
+// installPlugins installs plugins concurrently and returns a result for
+// each, in the order given. Incompatible plugins are not downloaded. The
+// configuration is left untouched.
+func installPlugins(plugins []*Plugin) []pluginInstallResult {
+	results := make([]pluginInstallResult, len(plugins))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < pluginInstallWorkers && i < len(plugins); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				plugin := plugins[j]
+				err := plugin.CheckCompatible()
+				if err == nil {
+					err = plugin.Install()
+				}
+				results[j] = pluginInstallResult{Plugin: plugin, Err: err}
+			}
+		}()
+	}
+	for i := range plugins {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// DevVersion is the version given to plugins linked for local development.
+const DevVersion = "0.0.0-dev"
+
+var (
+	flagLinkName     string
+	flagLinkUse      string
+	flagLinkShort    string
+	flagLinkDescribe bool
+)
+
+func init() {
+	pluginsLinkCmd.Flags().StringVarP(&flagLinkName, "name", "", "", "Name of the plugin")
+	pluginsLinkCmd.Flags().StringVarP(&flagLinkUse, "use", "", "", "Command provided by the plugin (defaults to the name without \"kel-\")")
+	pluginsLinkCmd.Flags().StringVarP(&flagLinkShort, "short", "", "", "Short description of the command")
+	pluginsLinkCmd.Flags().BoolVarP(&flagLinkDescribe, "describe", "", false, "Plugin supports the describe protocol")
+}
+
+var pluginsLinkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Use a local plugin binary or Go package for the activated site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins link <path> --name <name> [--use <command>]\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		if flagLinkName == "" {
+			usage("missing plugin name (specify with --name)")
+		}
+		siteConfig := GetActivatedSiteConfig()
+		if siteConfig == nil {
+			fatal("no site is activated for this directory.")
+		}
+		source, err := filepath.Abs(args[0])
+		if err != nil {
+			fatal(fmt.Sprintf("failed to resolve %s (%s)", args[0], err.Error()))
+		}
+		if _, err := os.Stat(source); err != nil {
+			fatal(fmt.Sprintf("failed to link %s (%s)", source, err.Error()))
+		}
+		use := flagLinkUse
+		if use == "" {
+			use = strings.TrimPrefix(flagLinkName, "kel-")
+		}
+		plugin := &Plugin{
+			Name:    flagLinkName,
+			Version: DevVersion,
+			Dev:     true,
+			Source:  source,
+			Command: PluginCommand{
+				Use:      use,
+				Short:    flagLinkShort,
+				Describe: flagLinkDescribe,
+			},
+		}
+		if siteConfig.Linked == nil {
+			siteConfig.Linked = make(map[string]string)
+		}
+		if _, ok := siteConfig.Linked[plugin.Name]; !ok {
+			siteConfig.Linked[plugin.Name] = siteConfig.Plugins[plugin.Name]
+		}
+		if siteConfig.LinkedPlugins == nil {
+			siteConfig.LinkedPlugins = make(map[string]*Plugin)
+		}
+		// kept with the site so other sites can link their own copy
+		siteConfig.LinkedPlugins[plugin.Name] = plugin
+		siteConfig.AddPlugin(plugin)
+		config.Save()
+		success(fmt.Sprintf("linked %q to %s.", plugin.Name, source))
+	},
+}
+
+var pluginsUnlinkCmd = &cobra.Command{
+	Use:   "unlink",
+	Short: "Stop using a linked plugin for the activated site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins unlink <name>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		name := args[0]
+		siteConfig := GetActivatedSiteConfig()
+		if siteConfig == nil {
+			fatal("no site is activated for this directory.")
+		}
+		previous, ok := siteConfig.Linked[name]
+		if !ok {
+			fatal(fmt.Sprintf("plugin %q is not linked.", name))
+		}
+		delete(siteConfig.Linked, name)
+		delete(siteConfig.LinkedPlugins, name)
+		if previous != "" {
+			siteConfig.Plugins[name] = previous
+		} else {
+			delete(siteConfig.Plugins, name)
+		}
+		config.Save()
+		if previous != "" {
+			success(fmt.Sprintf("unlinked %q; using released version %s.", name, previous))
+		} else {
+			success(fmt.Sprintf("unlinked %q.", name))
+		}
+	},
+}
+
+// Executable returns the program to run for the plugin and the arguments
+// preceding the plugin arguments. Linked Go packages are run with go run.
+func (plugin *Plugin) Executable() (string, []string, error) {
+	if !plugin.Dev {
+		return plugin.BinaryPath(), nil, nil
+	}
+	fi, err := os.Stat(plugin.Source)
+	if err != nil {
+		return "", nil, err
+	}
+	if !fi.IsDir() {
+		return plugin.Source, nil, nil
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return "", nil, fmt.Errorf("go is required to run %s (%s)", plugin.Source, err.Error())
+	}
+	return goBin, []string{"run", plugin.Source}, nil
+}
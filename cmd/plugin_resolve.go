@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver"
+)
+
+const (
+	candidateSelected   = "selected"
+	candidateLower      = "lower than selected version"
+	candidateOutOfRange = "does not satisfy range"
+	candidateBadVersion = "invalid version (skipped)"
+	candidateLinked     = "linked for development"
+)
+
+// candidateIncompatible is prefixed to the reason a candidate cannot run
+// with this kel client.
+const candidateIncompatible = "incompatible: "
+
+// PluginCandidate is an installed plugin considered while resolving a
+// version range.
+type PluginCandidate struct {
+	Plugin *Plugin
+	Reason string
+}
+
+// PluginResolution records the outcome of resolving a plugin version range
+// against the installed plugins.
+type PluginResolution struct {
+	Name       string
+	Range      string
+	Plugin     *Plugin
+	Candidates []PluginCandidate
+	// Incompatible is the highest version in range that was skipped for
+	// being incompatible with this kel client, along with the reason.
+	Incompatible      *Plugin
+	IncompatibleError error
+}
+
+// ResolvePlugin will pick the highest installed version of the named plugin
+// that satisfies versionRange and is compatible with this kel client.
+// Installed plugins with an invalid version are skipped. Plugin is nil on
+// the result when nothing matches.
+func ResolvePlugin(name, versionRange string) (*PluginResolution, error) {
+	vRange, err := semver.ParseRange(versionRange)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %q version range %q is invalid", name, versionRange)
+	}
+	resolution := &PluginResolution{
+		Name:  name,
+		Range: versionRange,
+	}
+	for _, plugin := range installedPlugins(name) {
+		candidate := PluginCandidate{Plugin: plugin}
+		v, err := semver.Make(plugin.Version)
+		switch {
+		case err != nil:
+			candidate.Reason = candidateBadVersion
+		case !vRange(v):
+			candidate.Reason = candidateOutOfRange
+		case resolution.Plugin != nil:
+			candidate.Reason = candidateLower
+		default:
+			if err := plugin.CheckCompatible(); err != nil {
+				candidate.Reason = candidateIncompatible + err.Error()
+				if resolution.Incompatible == nil {
+					resolution.Incompatible = plugin
+					resolution.IncompatibleError = err
+				}
+				break
+			}
+			candidate.Reason = candidateSelected
+			resolution.Plugin = plugin
+		}
+		resolution.Candidates = append(resolution.Candidates, candidate)
+	}
+	return resolution, nil
+}
+
+// resolveSitePlugin resolves a plugin like ResolvePlugin, except that a
+// plugin linked for development in siteConfig, which may be nil, is always
+// selected.
+func resolveSitePlugin(name, versionRange string, siteConfig *SiteConfig) (*PluginResolution, error) {
+	if siteConfig != nil {
+		if plugin, ok := siteConfig.LinkedPlugins[name]; ok {
+			return &PluginResolution{
+				Name:       name,
+				Range:      versionRange,
+				Plugin:     plugin,
+				Candidates: []PluginCandidate{{Plugin: plugin, Reason: candidateLinked}},
+			}, nil
+		}
+	}
+	return ResolvePlugin(name, versionRange)
+}
+
+// Warn will print a warning for each candidate skipped because of an invalid
+// version.
+func (resolution *PluginResolution) Warn() {
+	for _, candidate := range resolution.Candidates {
+		if candidate.Reason == candidateBadVersion {
+			warning(fmt.Sprintf("ignoring plugin %q with invalid version %q.", candidate.Plugin.Name, candidate.Plugin.Version))
+		}
+	}
+}
+
+// installedPlugins returns the installed plugins with the given name ordered
+// from highest to lowest version. Plugins with invalid versions come last.
+func installedPlugins(name string) []*Plugin {
+	var plugins pluginsByVersion
+	for _, plugin := range config.Plugins {
+		if plugin.Name == name {
+			plugins = append(plugins, plugin)
+		}
+	}
+	sort.Sort(plugins)
+	return plugins
+}
+
+// pluginsByVersion sorts plugins by descending semver version. Equal
+// versions are ordered by their version string so the order is stable.
+type pluginsByVersion []*Plugin
+
+func (p pluginsByVersion) Len() int      { return len(p) }
+func (p pluginsByVersion) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p pluginsByVersion) Less(i, j int) bool {
+	vi, erri := semver.Make(p[i].Version)
+	vj, errj := semver.Make(p[j].Version)
+	switch {
+	case erri != nil && errj != nil:
+		return p[i].Version < p[j].Version
+	case erri != nil:
+		return false
+	case errj != nil:
+		return true
+	}
+	if vi.EQ(vj) {
+		// versions differing only in build metadata
+		return p[i].Version > p[j].Version
+	}
+	return vi.GT(vj)
+}
+
+// sortedPluginNames returns the keys of a plugin version range map in a
+// stable order.
+func sortedPluginNames(ranges map[string]string) []string {
+	names := make([]string, 0, len(ranges))
+	for name := range ranges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// effectivePluginRanges returns the version ranges of the plugins available
+// with siteConfig activated, which may be nil. A site pinning a plugin takes
+// precedence over the global range of the same plugin.
+func effectivePluginRanges(siteConfig *SiteConfig) map[string]string {
+	ranges := make(map[string]string)
+	for name, versionRange := range config.GlobalPlugins {
+		ranges[name] = versionRange
+	}
+	if siteConfig != nil {
+		for name, versionRange := range siteConfig.Plugins {
+			ranges[name] = versionRange
+		}
+	}
+	return ranges
+}
+
+// pluginRangeSource describes where the effective range of a plugin comes
+// from.
+func pluginRangeSource(name string, siteConfig *SiteConfig) string {
+	if siteConfig != nil {
+		if _, ok := siteConfig.Plugins[name]; ok {
+			return "site plugin"
+		}
+	}
+	return "global plugin"
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"testing"
+)
+
+// withInstalledPlugins replaces the installed plugins and returns a func
+// restoring them.
+func withInstalledPlugins(plugins ...*Plugin) func() {
+	saved := config.Plugins
+	config.Plugins = make(map[string]*Plugin)
+	for _, plugin := range plugins {
+		config.Plugins[plugin.String()] = plugin
+	}
+	return func() { config.Plugins = saved }
+}
+
+func TestResolvePlugin(t *testing.T) {
+	defer withInstalledPlugins(
+		&Plugin{Name: "kel-build", Version: "0.1.0"},
+		&Plugin{Name: "kel-build", Version: "0.2.0"},
+		&Plugin{Name: "kel-build", Version: "1.0.0-beta.1"},
+		&Plugin{Name: "kel-build", Version: "1.0.0"},
+		&Plugin{Name: "kel-build", Version: "1.0.0+b"},
+		&Plugin{Name: "kel-build", Version: "1.0.0+a"},
+		&Plugin{Name: "kel-build", Version: "1.1.0", Protocol: 99},
+		&Plugin{Name: "kel-build", Version: "latest"},
+		&Plugin{Name: "kel-deploy", Version: "3.0.0"},
+	)()
+	tests := []struct {
+		versionRange string
+		version      string
+		incompatible string
+	}{
+		{">=0.0.0", "1.0.0+b", "1.1.0"},
+		// prereleases sort below their release
+		{"<1.0.0", "1.0.0-beta.1", ""},
+		{"<0.3.0", "0.2.0", ""},
+		{"=0.1.0", "0.1.0", ""},
+		{">=0.1.0 <0.2.0", "0.1.0", ""},
+		{"=1.0.0-beta.1", "1.0.0-beta.1", ""},
+		{">=1.1.0", "", "1.1.0"},
+		{">=2.0.0", "", ""},
+	}
+	for _, test := range tests {
+		resolution, err := ResolvePlugin("kel-build", test.versionRange)
+		if err != nil {
+			t.Fatalf("%s: %v", test.versionRange, err)
+		}
+		version := ""
+		if resolution.Plugin != nil {
+			version = resolution.Plugin.Version
+		}
+		if version != test.version {
+			t.Errorf("%s: resolved %q, want %q", test.versionRange, version, test.version)
+		}
+		incompatible := ""
+		if resolution.Incompatible != nil {
+			incompatible = resolution.Incompatible.Version
+		}
+		if incompatible != test.incompatible {
+			t.Errorf("%s: incompatible %q, want %q", test.versionRange, incompatible, test.incompatible)
+		}
+	}
+}
+
+func TestResolvePluginCandidates(t *testing.T) {
+	defer withInstalledPlugins(
+		&Plugin{Name: "kel-build", Version: "0.1.0"},
+		&Plugin{Name: "kel-build", Version: "0.2.0"},
+		&Plugin{Name: "kel-build", Version: "2.0.0"},
+		&Plugin{Name: "kel-build", Version: "bogus"},
+	)()
+	resolution, err := ResolvePlugin("kel-build", "<1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ version, reason string }{
+		{"2.0.0", candidateOutOfRange},
+		{"0.2.0", candidateSelected},
+		{"0.1.0", candidateLower},
+		{"bogus", candidateBadVersion},
+	}
+	if len(resolution.Candidates) != len(want) {
+		t.Fatalf("got %d candidates, want %d", len(resolution.Candidates), len(want))
+	}
+	for i, candidate := range resolution.Candidates {
+		if candidate.Plugin.Version != want[i].version || candidate.Reason != want[i].reason {
+			t.Errorf("candidate %d = %s (%s), want %s (%s)", i, candidate.Plugin.Version, candidate.Reason, want[i].version, want[i].reason)
+		}
+	}
+}
+
+func TestResolvePluginInvalidRange(t *testing.T) {
+	if _, err := ResolvePlugin("kel-build", "not a range"); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+}
+
+func TestResolveSitePluginLinked(t *testing.T) {
+	defer withInstalledPlugins(&Plugin{Name: "kel-build", Version: "0.1.0"})()
+	linked := func(source string) *SiteConfig {
+		return &SiteConfig{LinkedPlugins: map[string]*Plugin{
+			"kel-build": {Name: "kel-build", Version: DevVersion, Dev: true, Source: source},
+		}}
+	}
+	tests := []struct {
+		siteConfig *SiteConfig
+		source     string
+	}{
+		{linked("/src/a"), "/src/a"},
+		{linked("/src/b"), "/src/b"},
+		{&SiteConfig{}, ""},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		resolution, err := resolveSitePlugin("kel-build", "="+DevVersion, test.siteConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		source := ""
+		if resolution.Plugin != nil {
+			source = resolution.Plugin.Source
+		}
+		if source != test.source {
+			t.Errorf("resolved source %q, want %q", source, test.source)
+		}
+	}
+}
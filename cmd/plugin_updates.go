@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+const updateCheckInterval = 24 * time.Hour
+
+func init() {
+	RootCmd.AddCommand(updateCheckCmd)
+}
+
+// updateCheckState is cached in ~/.kel/update-check.json between runs.
+type updateCheckState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// Latest maps plugin names to the newest version known to the site
+	// manifest or the plugin indexes.
+	Latest map[string]string `json:"latest"`
+}
+
+func updateCheckPath() string {
+	return path.Join(getConfigDir(), "update-check.json")
+}
+
+// updateCheckEnabled reports whether update notices may be shown. They are
+// disabled by --no-input, on CI servers and by kel config set update-check
+// false.
+func updateCheckEnabled() bool {
+	if flagNoInput || config.DisableUpdateCheck {
+		return false
+	}
+	if os.Getenv("CI") != "" || os.Getenv("CONTINUOUS_INTEGRATION") != "" || os.Getenv("BUILD_NUMBER") != "" {
+		return false
+	}
+	return true
+}
+
+// startUpdateCheck prints a notice for each enabled plugin with a newer
+// version available, based on the last check. When that check is older than
+// a day, a new one is recorded as started and run by a detached kel process
+// so that it neither delays nor depends on this one.
+func startUpdateCheck() {
+	if !updateCheckEnabled() {
+		return
+	}
+	var state updateCheckState
+	if buf, err := ioutil.ReadFile(updateCheckPath()); err == nil {
+		json.Unmarshal(buf, &state)
+	}
+	siteConfig := GetActivatedSiteConfig()
+	for _, plugin := range resolvePlugins(effectivePluginRanges(siteConfig), siteConfig) {
+		latest, ok := state.Latest[plugin.Name]
+		if !ok || plugin.Dev {
+			continue
+		}
+		if newerVersion(latest, plugin.Version) {
+			fmt.Fprintf(os.Stderr, "%s %s available (you have %s)\n", plugin.Name, yellow(latest), plugin.Version)
+		}
+	}
+	if time.Since(state.CheckedAt) < updateCheckInterval {
+		return
+	}
+	// record the attempt first so a slow or failing check runs once a day
+	state.CheckedAt = time.Now()
+	if err := writeUpdateCheckState(&state); err != nil {
+		return
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+	c := exec.Command(executable, updateCheckCmd.Use)
+	if c.Start() == nil {
+		c.Process.Release()
+	}
+}
+
+var updateCheckCmd = &cobra.Command{
+	Use:    "__update-check",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		state := updateCheckState{
+			CheckedAt: time.Now(),
+			Latest:    latestPluginVersions(GetActivatedSiteConfig()),
+		}
+		writeUpdateCheckState(&state)
+	},
+}
+
+func writeUpdateCheckState(state *updateCheckState) error {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(updateCheckPath(), buf, 0644)
+}
+
+// latestPluginVersions returns the newest version of every plugin offered by
+// the plugin indexes and the manifest of the activated site.
+func latestPluginVersions(siteConfig *SiteConfig) map[string]string {
+	candidates := indexPluginsQuiet()
+	if siteConfig != nil && siteConfig.URI != nil {
+		site := &kel.Site{
+			ResourceGroup: &kel.ResourceGroup{Name: siteConfig.URI.ResourceGroup},
+			Name:          siteConfig.URI.Site,
+		}
+		candidates = append(candidates, fetchSitePlugins(site)...)
+	}
+	latest := make(map[string]string)
+	for _, plugin := range candidates {
+		if current, ok := latest[plugin.Name]; !ok || newerVersion(plugin.Version, current) {
+			latest[plugin.Name] = plugin.Version
+		}
+	}
+	return latest
+}
+
+// indexPluginsQuiet is indexPlugins without warnings for unreadable
+// indexes, which would only be noise in a background check.
+func indexPluginsQuiet() []*Plugin {
+	var plugins []*Plugin
+	for _, index := range config.PluginIndexes {
+		entries, err := fetchPluginIndex(index)
+		if err != nil {
+			continue
+		}
+		plugins = append(plugins, entries...)
+	}
+	return plugins
+}
+
+// newerVersion reports whether version a is a valid version greater than b.
+func newerVersion(a, b string) bool {
+	va, err := semver.Make(a)
+	if err != nil {
+		return false
+	}
+	vb, err := semver.Make(b)
+	if err != nil {
+		return false
+	}
+	return va.GT(vb)
+}
@@ -2,80 +2,303 @@ package cmd
 
 import (
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path"
-	"strings"
-	"syscall"
 	"time"
 
-	"github.com/blang/semver"
 	"github.com/kelproject/kel-go"
 	"github.com/spf13/cobra"
 )
 
+var (
+	flagGlobal bool
+)
+
+func init() {
+	RootCmd.AddCommand(pluginsCmd)
+	pluginsCmd.AddCommand(
+		pluginsListCmd,
+		pluginsResolveCmd,
+		pluginsEnableCmd,
+		pluginsDisableCmd,
+		pluginsLinkCmd,
+		pluginsUnlinkCmd,
+	)
+	pluginsEnableCmd.Flags().BoolVarP(&flagGlobal, "global", "", false, "Enable the plugin in every directory")
+	pluginsDisableCmd.Flags().BoolVarP(&flagGlobal, "global", "", false, "Disable the globally enabled plugin")
+}
+
 // Plugin represents a Kel client plugin.
 type Plugin struct {
 	Name    string        `json:"name,omitempty"`
 	Version string        `json:"version,omitempty"`
 	Command PluginCommand `json:"command,omitempty"`
+	// Dev is set for plugins linked from a local path for development.
+	Dev    bool   `json:"dev,omitempty"`
+	Source string `json:"source,omitempty"`
+	// Hooks lists the kel events the plugin handles.
+	Hooks []string `json:"hooks,omitempty"`
+	// SHA256 is the expected digest of the plugin binary, if known.
+	SHA256 string `json:"sha256,omitempty"`
+	// KelVersion is the semver range of kel clients the plugin works with
+	// and Protocol the version of the context protocol it expects.
+	KelVersion string `json:"kel_version,omitempty"`
+	Protocol   int    `json:"protocol,omitempty"`
 }
 
 // PluginCommand represents the client command plugin
 type PluginCommand struct {
-	BinaryURL string `json:"binary_url,omitempty"`
-	Use       string `json:"use,omitempty"`
-	Short     string `json:"short,omitempty"`
+	BinaryURL string   `json:"binary_url,omitempty"`
+	Use       string   `json:"use,omitempty"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Short     string   `json:"short,omitempty"`
+	// Describe is set when the plugin binary answers the describe protocol.
+	Describe bool `json:"describe,omitempty"`
 }
 
-// LoadPlugins will load configured plugins for the activated site.
-func LoadPlugins() {
-	siteConfig := GetActivatedSiteConfig()
-	if siteConfig != nil {
-		for pluginName, pluginVersionRange := range siteConfig.Plugins {
-			var plugin *Plugin
-			vRange, err := semver.ParseRange(pluginVersionRange)
-			if err != nil {
-				fatal(fmt.Sprintf("site plugin %q version range %q is invalid.", pluginName, pluginVersionRange))
+// loadedPlugins are the plugins made available as commands by LoadPlugins.
+var loadedPlugins []*Plugin
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "Manage plugins",
+}
+
+var pluginsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed plugins",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins list\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		var plugins []*Plugin
+		names := make(map[string]string)
+		for _, plugin := range config.Plugins {
+			names[plugin.Name] = ""
+		}
+		for _, name := range sortedPluginNames(names) {
+			plugins = append(plugins, installedPlugins(name)...)
+		}
+		if siteConfig := GetActivatedSiteConfig(); siteConfig != nil {
+			linked := make(map[string]string)
+			for name := range siteConfig.LinkedPlugins {
+				linked[name] = ""
+			}
+			for _, name := range sortedPluginNames(linked) {
+				plugins = append(plugins, siteConfig.LinkedPlugins[name])
+			}
+		}
+		if flagOutput == OutputJSON {
+			printJSON(plugins)
+			return
+		}
+		w := newTabWriter()
+		for _, plugin := range plugins {
+			if plugin.Dev {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", plugin.Name, plugin.Version, yellow("dev"), plugin.Source)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t\t\n", plugin.Name, plugin.Version)
+			}
+		}
+		w.Flush()
+	},
+}
+
+var pluginsEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable an installed plugin for the activated site or globally",
+	Long: `Enable an installed plugin for the activated site, or with --global in every
+directory. When a site enables a plugin that is also enabled globally, the
+version range of the site takes precedence.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins enable [--global] <name> [version-range]\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 2 {
+			usage("too many arguments")
+		}
+		name := args[0]
+		versionRange := ">=0.0.0"
+		if len(args) == 2 {
+			versionRange = args[1]
+		}
+		resolution, err := ResolvePlugin(name, versionRange)
+		if err != nil {
+			fatal(err.Error())
+		}
+		if resolution.Plugin == nil {
+			fatal(fmt.Sprintf("no installed plugin matching %s %s (install it with kel plugins install).", name, versionRange))
+		}
+		if flagGlobal {
+			config.EnableGlobalPlugin(name, versionRange)
+		} else {
+			siteConfig := GetActivatedSiteConfig()
+			if siteConfig == nil {
+				fatal("no site is activated for this directory (use --global to enable it everywhere).")
 			}
-			for _, plugin = range config.Plugins {
-				v, err := semver.Make(plugin.Version)
-				if err != nil {
-					fatal(fmt.Sprintf("plugin %q version %q is invalid.", plugin.Name, plugin.Version))
-				}
-				if plugin.Name == pluginName && vRange(v) {
-					break
-				}
-				plugin = nil
+			siteConfig.EnablePlugin(name, versionRange)
+		}
+		config.Save()
+		success(fmt.Sprintf("enabled %s %s.", name, versionRange))
+	},
+}
+
+var pluginsDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable a plugin for the activated site or globally",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins disable [--global] <name>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		name := args[0]
+		ranges := config.GlobalPlugins
+		if !flagGlobal {
+			siteConfig := GetActivatedSiteConfig()
+			if siteConfig == nil {
+				fatal("no site is activated for this directory (use --global for global plugins).")
 			}
-			if plugin == nil {
-				fatal(fmt.Sprintf("plugin matching %s %s was not found.", pluginName, pluginVersionRange))
+			ranges = siteConfig.Plugins
+		}
+		if _, ok := ranges[name]; !ok {
+			fatal(fmt.Sprintf("plugin %q is not enabled.", name))
+		}
+		delete(ranges, name)
+		config.Save()
+		success(fmt.Sprintf("disabled %s.", name))
+	},
+}
+
+var pluginsResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Explain which plugin versions are used in this directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel plugins resolve [name]\n")
+			fatal(msg)
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		siteConfig := GetActivatedSiteConfig()
+		ranges := effectivePluginRanges(siteConfig)
+		if len(ranges) == 0 {
+			fatal("no plugins are enabled for this directory.")
+		}
+		if len(args) == 1 {
+			if _, ok := ranges[args[0]]; !ok {
+				fatal(fmt.Sprintf("plugin %q is not enabled for this directory.", args[0]))
+			}
+		}
+		for _, pluginName := range sortedPluginNames(ranges) {
+			if len(args) == 1 && args[0] != pluginName {
+				continue
+			}
+			resolution, err := resolveSitePlugin(pluginName, ranges[pluginName], siteConfig)
+			if err != nil {
+				failure(err.Error())
+				continue
 			}
-			RootCmd.AddCommand(plugin.AsCmd())
-			// prevent flag parsing for the plugin command
-			args := os.Args[1:]
-			if len(args) >= 1 {
-				if strings.HasPrefix(plugin.Command.Use, args[0]) && len(args[1:]) > 0 {
-					args = append(args[0:1], append([]string{"--"}, args[1:]...)...)
-				}
+			source := pluginRangeSource(pluginName, siteConfig)
+			if resolution.Plugin != nil {
+				fmt.Printf("%s %s (%s): %s\n", pluginName, resolution.Range, source, green(resolution.Plugin.Version))
+			} else {
+				fmt.Printf("%s %s (%s): %s\n", pluginName, resolution.Range, source, red("no match"))
 			}
+			for _, candidate := range resolution.Candidates {
+				fmt.Printf("  %-12s %s\n", candidate.Plugin.Version, candidate.Reason)
+			}
+		}
+	},
+}
+
+// LoadPlugins will load the global plugins and those configured for the
+// activated site.
+func LoadPlugins() {
+	siteConfig := GetActivatedSiteConfig()
+	ranges := effectivePluginRanges(siteConfig)
+	for _, pluginName := range sortedPluginNames(ranges) {
+		pluginVersionRange := ranges[pluginName]
+		resolution, err := resolveSitePlugin(pluginName, pluginVersionRange, siteConfig)
+		if err != nil {
+			// keep kel usable so the configuration can be repaired
+			warning(fmt.Sprintf("skipping %s: %s.", pluginRangeSource(pluginName, siteConfig), err.Error()))
+			continue
+		}
+		resolution.Warn()
+		plugin := resolution.Plugin
+		if plugin == nil && resolution.Incompatible != nil {
+			// refuse to run it, but keep the other commands usable
+			RootCmd.AddCommand(incompatibleCmd(resolution.Incompatible, resolution.IncompatibleError))
+			continue
+		}
+		if plugin == nil {
+			warning(fmt.Sprintf("skipping %s %s: no installed version matches %s (see kel plugins resolve).", pluginRangeSource(pluginName, siteConfig), pluginName, pluginVersionRange))
+			continue
+		}
+		RootCmd.AddCommand(plugin.AsCmd())
+		loadedPlugins = append(loadedPlugins, plugin)
+	}
+	if args, plugin := routePluginArgs(os.Args[1:], RootCmd.PersistentFlags(), loadedPlugins); plugin != nil {
+		// described plugins get their help rendered by kel
+		if plugin.Description() == nil || !helpRequested(os.Args[1:]) {
 			RootCmd.SetArgs(args)
 		}
 	}
 }
 
 // SyncSitePlugins will sync the local state of plugins match what the site
-// is providing.
-func SyncSitePlugins(site *kel.Site) {
-	siteConfig := GetActivatedSiteConfig()
+// is providing. The caller is responsible for saving the configuration once
+// it succeeds.
+func SyncSitePlugins(site *kel.Site, siteConfig *SiteConfig) {
+	fmt.Printf("Fetching plugins... ")
+	plugins := fetchSitePlugins(site)
+	fmt.Println(green("done"))
 
-	var plugins []*Plugin
+	var pending []*Plugin
+	for _, plugin := range plugins {
+		if _, ok := config.Plugins[plugin.String()]; !ok {
+			pending = append(pending, plugin)
+		}
+	}
+	failed := 0
+	for _, result := range installPlugins(pending) {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("Installing plugin %q... %s\n", result.Plugin.Name, red("error"))
+			failure(result.Err.Error())
+			continue
+		}
+		fmt.Printf("Installing plugin %q... %s (version: %s)\n", result.Plugin.Name, green("installed"), whiteBold(result.Plugin.Version))
+	}
+	if failed > 0 {
+		fatal(fmt.Sprintf("%d of %d plugins failed to install; the site was not activated.", failed, len(pending)))
+	}
+	// all plugins are installed; record them together
+	for _, plugin := range plugins {
+		config.AddPlugin(plugin)
+		siteConfig.AddPlugin(plugin)
+	}
+}
 
-	fmt.Printf("Fetching plugins... ")
+// fetchSitePlugins returns the plugin manifests the site provides.
+func fetchSitePlugins(site *kel.Site) []*Plugin {
 	time.Sleep(2 * time.Second)
-	plugins = append(
-		plugins,
+	return []*Plugin{
 		&Plugin{
 			Name:    "kel-build",
 			Version: "0.1.0",
@@ -94,53 +317,27 @@ func SyncSitePlugins(site *kel.Site) {
 				BinaryURL: "http://localhost:8080/kel-deploy",
 			},
 		},
-	)
-	fmt.Println(green("done"))
-
-	for _, plugin := range plugins {
-		if _, ok := config.Plugins[plugin.String()]; !ok {
-			fmt.Printf("Installing plugin %q... ", plugin.Name)
-			if err := plugin.Install(); err != nil {
-				fmt.Printf("%s\n", red("error"))
-				fatal(err.Error())
-			}
-			siteConfig.AddPlugin(plugin)
-			config.AddPlugin(plugin)
-			fmt.Printf("%s (version: %s)\n", green("installed"), whiteBold(plugin.Version))
-		}
 	}
-
-	config.Save()
 }
 
-// Install will download and install the plugin binary.
+// Install will install the plugin binary, downloading it into the cache
+// unless a cached copy is available.
 func (plugin *Plugin) Install() error {
+	if plugin.Dev {
+		// linked plugins run straight from their source
+		return nil
+	}
 	pluginDir := path.Dir(plugin.BinaryPath())
 	if _, err := os.Stat(pluginDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(pluginDir, os.FileMode(0755)); err != nil {
-			if err != nil {
-				return fmt.Errorf("unable to create directory %q: %s", pluginDir, err.Error())
-			}
+			return fmt.Errorf("unable to create directory %q: %s", pluginDir, err.Error())
 		}
 	}
-	f, err := os.Create(plugin.BinaryPath())
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	resp, err := http.Get(plugin.Command.BinaryURL)
+	blob, err := fetchCached(plugin.Command.BinaryURL, plugin.SHA256)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	_, err = io.Copy(f, resp.Body)
-	if err != nil {
-		return err
-	}
-	if err := f.Chmod(os.FileMode(0755)); err != nil {
-		return err
-	}
-	return nil
+	return copyFile(blob, plugin.BinaryPath(), os.FileMode(0755))
 }
 
 // BinaryPath will return the full filesystem path to the binary for this
@@ -153,19 +350,21 @@ func (plugin *Plugin) BinaryPath() string {
 	)
 }
 
-// AsCmd returns a cobra.Command based on dynamic plugin values.
+// AsCmd returns a cobra.Command based on dynamic plugin values. Plugins
+// supporting the describe protocol get a full command tree.
 func (plugin *Plugin) AsCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   plugin.Command.Use,
-		Short: plugin.Command.Short,
+	cmd := &cobra.Command{
+		Use:     plugin.Command.Use,
+		Aliases: plugin.Command.Aliases,
+		Short:   plugin.Command.Short,
 		Run: func(cmd *cobra.Command, args []string) {
-			args = append([]string{path.Base(plugin.BinaryPath())}, args...)
-			env := os.Environ()
-			if err := syscall.Exec(plugin.BinaryPath(), args, env); err != nil {
-				fatal(fmt.Sprintf("failed executing plugin %q binary %s: %s", plugin.Name, plugin.BinaryPath(), err.Error()))
-			}
+			os.Exit(plugin.Run(args))
 		},
 	}
+	if description := plugin.Description(); description != nil {
+		plugin.describeCmd(cmd, description, nil)
+	}
+	return cmd
 }
 
 func (plugin *Plugin) String() string {
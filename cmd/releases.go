@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+// releaseDetails is a release of a site. Every build, configuration change
+// and rollback creates a new release.
+type releaseDetails struct {
+	Version     int         `json:"version"`
+	Author      string      `json:"author"`
+	Created     time.Time   `json:"created"`
+	Description string      `json:"description"`
+	Changes     releaseDiff `json:"changes"`
+	Env         []*envVar   `json:"env,omitempty"`
+}
+
+// releaseDiff summarizes what changed from the previous release.
+type releaseDiff struct {
+	Build   string   `json:"build,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+func init() {
+	RootCmd.AddCommand(releasesCmd, rollbackCmd)
+	releasesCmd.AddCommand(
+		releasesListCmd,
+		releasesShowCmd,
+	)
+	releasesListCmd.Flags().BoolVarP(&flagReveal, "reveal", "", false, "Show the values of secret variables")
+	releasesShowCmd.Flags().BoolVarP(&flagReveal, "reveal", "", false, "Show the values of secret variables")
+	rollbackCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Roll back without asking for confirmation")
+}
+
+var releasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "Inspect releases of the activated site",
+}
+
+var releasesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List releases, newest first",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel releases list [--reveal]\n")
+			fatal(msg)
+		}
+		if len(args) > 0 {
+			usage("too many arguments")
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		releases := fetchReleases(setupAPIClient(uri), uri)
+		if flagOutput == OutputJSON {
+			for _, release := range releases {
+				release.Env = maskEnv(release.Env)
+			}
+			printJSON(releases)
+			return
+		}
+		w := newTabWriter()
+		fmt.Fprintf(w, "VERSION\tAUTHOR\tCREATED\tDESCRIPTION\tCHANGES\n")
+		for _, release := range releases {
+			fmt.Fprintf(w, "v%d\t%s\t%s\t%s\t%s\n", release.Version, release.Author, formatTime(release.Created), release.Description, release.Changes.summary())
+		}
+		w.Flush()
+	},
+}
+
+var releasesShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show details of a release",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel releases show [--reveal] <version>\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		version, err := parseReleaseVersion(args[0])
+		if err != nil {
+			usage(err.Error())
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		var release releaseDetails
+		if err := setupAPIClient(uri).Do("GET", releasePath(uri, version), "", nil, &release); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("release v%d of %s/%s does not exist.", version, uri.ResourceGroup, uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to get release (error: %v)", err))
+		}
+		if flagOutput == OutputJSON {
+			release.Env = maskEnv(release.Env)
+			printJSON(release)
+			return
+		}
+		w := newTabWriter()
+		fmt.Fprintf(w, "Version:\tv%d\n", release.Version)
+		fmt.Fprintf(w, "Author:\t%s\n", release.Author)
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(release.Created))
+		fmt.Fprintf(w, "Description:\t%s\n", release.Description)
+		if release.Changes.Build != "" {
+			fmt.Fprintf(w, "Build:\t%s\n", release.Changes.Build)
+		}
+		w.Flush()
+		for _, name := range release.Changes.Added {
+			fmt.Println(green("+ " + name))
+		}
+		for _, name := range release.Changes.Changed {
+			fmt.Println(yellow("~ " + name))
+		}
+		for _, name := range release.Changes.Removed {
+			fmt.Println(red("- " + name))
+		}
+		if len(release.Env) > 0 {
+			fmt.Println("\nEnvironment:")
+			w = newTabWriter()
+			for _, v := range release.Env {
+				fmt.Fprintf(w, "  %s\t%s\n", v.Name, v.display())
+			}
+			w.Flush()
+		}
+	},
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Create a new release from a previous one",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel rollback [--yes] [<version>]\n")
+			fatal(msg)
+		}
+		if len(args) > 1 {
+			usage("too many arguments")
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		api := setupAPIClient(uri)
+		releases := fetchReleases(api, uri)
+		if len(releases) == 0 {
+			fatal(fmt.Sprintf("%s/%s has no releases.", uri.ResourceGroup, uri.Site))
+		}
+		current := releases[0]
+		var target *releaseDetails
+		if len(args) == 1 {
+			version, err := parseReleaseVersion(args[0])
+			if err != nil {
+				usage(err.Error())
+			}
+			for _, release := range releases {
+				if release.Version == version {
+					target = release
+				}
+			}
+			if target == nil {
+				fatal(fmt.Sprintf("release v%d of %s/%s does not exist.", version, uri.ResourceGroup, uri.Site))
+			}
+			if target == current {
+				fatal(fmt.Sprintf("v%d is the current release.", version))
+			}
+		} else {
+			if len(releases) < 2 {
+				fatal("there is no previous release to roll back to.")
+			}
+			target = releases[1]
+		}
+		fmt.Fprintf(os.Stderr, "Current release is v%d (%s).\n", current.Version, current.Description)
+		fmt.Fprintf(os.Stderr, "Rolling back creates a new release from v%d (%s).\n", target.Version, target.Description)
+		if !flagYes && !confirm(fmt.Sprintf("Roll back %s/%s to v%d?", uri.ResourceGroup, uri.Site, target.Version)) {
+			fatal("rollback cancelled.")
+		}
+		var release releaseDetails
+		if err := api.Do("POST", sitePath(uri)+"releases/", "releases", map[string]int{"rollback": target.Version}, &release); err != nil {
+			fatal(fmt.Sprintf("failed to roll back (error: %v)", err))
+		}
+		success(fmt.Sprintf("rolled back to v%d (new release v%d).", target.Version, release.Version))
+	},
+}
+
+// fetchReleases returns the releases of a site, newest first.
+func fetchReleases(api *apiClient, uri URI) []*releaseDetails {
+	var releases []*releaseDetails
+	if err := api.List(sitePath(uri)+"releases/", &releases); err != nil {
+		if err == kel.ErrNotFound {
+			fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+		}
+		fatal(fmt.Sprintf("failed to list releases (error: %v)", err))
+	}
+	sort.Sort(releasesByVersion(releases))
+	return releases
+}
+
+// releasePath returns the API path of a release of the site named by uri.
+func releasePath(uri URI, version int) string {
+	return fmt.Sprintf("%sreleases/%d/", sitePath(uri), version)
+}
+
+// parseReleaseVersion accepts release versions with or without a v prefix.
+func parseReleaseVersion(value string) (int, error) {
+	version, err := strconv.Atoi(strings.TrimPrefix(value, "v"))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid release version %q", value)
+	}
+	return version, nil
+}
+
+func (diff releaseDiff) summary() string {
+	var parts []string
+	if diff.Build != "" {
+		parts = append(parts, "build "+diff.Build)
+	}
+	if n := len(diff.Added) + len(diff.Changed) + len(diff.Removed); n > 0 {
+		parts = append(parts, fmt.Sprintf("env +%d ~%d -%d", len(diff.Added), len(diff.Changed), len(diff.Removed)))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+type releasesByVersion []*releaseDetails
+
+func (s releasesByVersion) Len() int           { return len(s) }
+func (s releasesByVersion) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s releasesByVersion) Less(i, j int) bool { return s[i].Version > s[j].Version }
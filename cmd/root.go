@@ -7,61 +7,99 @@ import (
 
 	"github.com/bgentry/speakeasy"
 	"github.com/kelproject/kel-go"
+	kelplugin "github.com/kelproject/kel/plugin"
 	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
 )
 
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Version is the version of the kel client.
+var Version = "0.1.0"
+
 var (
-	flagURI string
+	flagURI     string
+	flagOutput  string
+	flagNoColor bool
+	flagNoInput bool
 )
 
 // RootCmd is ...
 var RootCmd = &cobra.Command{
 	Use:   "kel",
 	Short: "Kel end-user command-line tool",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		switch flagOutput {
+		case OutputText, OutputJSON:
+			break
+		default:
+			fatal(fmt.Sprintf("invalid output format %q (must be %s or %s)", flagOutput, OutputText, OutputJSON))
+		}
+		if !cmd.Hidden {
+			runHooks(kelplugin.HookPreCommand, GetActivatedSiteConfig(), kelplugin.EnvCommand+"="+cmd.CommandPath())
+			startUpdateCheck()
+		}
+	},
 }
 
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&flagURI, "uri", "", "", "URI for this invocation")
+	RootCmd.PersistentFlags().StringVarP(&flagOutput, "output", "o", OutputText, "Output format (text or json)")
+	RootCmd.PersistentFlags().BoolVarP(&flagNoColor, "no-color", "", false, "Disable colored output")
+	RootCmd.PersistentFlags().BoolVarP(&flagNoInput, "no-input", "", false, "Disable prompts and notices")
 }
 
-func getClusterAuthClient() *http.Client {
+const clusterAuthProvider = "identity.gondor.io"
+
+func getClusterOAuthConfig() *oauth2.Config {
 	oauth2.RegisterBrokenAuthHeaderProvider("https://identity.gondor.io/")
-	conf := &oauth2.Config{
+	return &oauth2.Config{
 		ClientID: "KtcICiPMAII8FAeArUoDB97zmjqltllyUDev8HOS",
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  "https://identity.gondor.io/oauth/authorize/",
 			TokenURL: "https://identity.gondor.io/oauth/token/",
 		},
 	}
-	ctx := oauth2.NoContext
-	var token *oauth2.Token
-	var ok bool
-	provider := "identity.gondor.io"
-	token, ok = config.Tokens[provider]
+}
+
+// getClusterTokenSource returns a token source for the stored cluster token
+// which persists refreshed tokens, or nil when the user has not logged in.
+func getClusterTokenSource() oauth2.TokenSource {
+	token, ok := config.Tokens[clusterAuthProvider]
 	if !ok {
-		var err error
+		return nil
+	}
+	ts := getClusterOAuthConfig().TokenSource(oauth2.NoContext, token)
+	tokenSaver := &configTokenSaver{provider: clusterAuthProvider}
+	return newCachedTokenSource(ts, tokenSaver)
+}
+
+func getClusterAuthClient() *http.Client {
+	conf := getClusterOAuthConfig()
+	ctx := oauth2.NoContext
+	provider := clusterAuthProvider
+	if _, ok := config.Tokens[provider]; !ok {
 		// ask for username
 		var username string
 		fmt.Printf("Username: ")
-		fmt.Scan(&username)
+		fmt.Fscan(stdin, &username)
 		// ask for password safely
-		var password string
-		password, err = speakeasy.Ask("Password: ")
+		password, err := speakeasy.Ask("Password: ")
 		if err != nil {
 			fatal(err.Error())
 		}
-		token, err = conf.PasswordCredentialsToken(ctx, username, password)
+		token, err := conf.PasswordCredentialsToken(ctx, username, password)
 		if err != nil {
 			fatal(err.Error())
 		}
 		config.Tokens[provider] = token
 		config.Save()
+		runHooks(kelplugin.HookPostLogin, GetActivatedSiteConfig())
 	}
-	ts := conf.TokenSource(oauth2.NoContext, token)
-	tokenSaver := &configTokenSaver{provider: provider}
-	cachedTokenSource := newCachedTokenSource(ts, tokenSaver)
-	return oauth2.NewClient(oauth2.NoContext, cachedTokenSource)
+	return oauth2.NewClient(oauth2.NoContext, getClusterTokenSource())
 }
 
 func setupAuth() *http.Client {
@@ -75,7 +113,8 @@ func setupAuth() *http.Client {
 	return hc
 }
 
-func setupKelClient(uri URI) *kel.Client {
+// apiBaseURL returns the base URL of the Kel API for the given URI.
+func apiBaseURL(uri URI) string {
 	parts := []string{}
 	if uri.Insecure {
 		parts = append(parts, "http://")
@@ -84,7 +123,11 @@ func setupKelClient(uri URI) *kel.Client {
 	}
 	parts = append(parts, uri.Host)
 	parts = append(parts, "/v1/self")
-	kc, err := kel.New(setupAuth(), strings.Join(parts, ""))
+	return strings.Join(parts, "")
+}
+
+func setupKelClient(uri URI) *kel.Client {
+	kc, err := kel.New(setupAuth(), apiBaseURL(uri))
 	if err != nil {
 		fatal(err.Error())
 	}
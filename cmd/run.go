@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// An attached process speaks a framed protocol over an upgraded HTTP
+// connection. Each frame is a stream byte, a big endian uint32 length and
+// the payload. kel sends stdin frames, an empty one closing stdin, and the
+// site sends stdout and stderr frames followed by an exit frame holding the
+// decimal exit code.
+const (
+	streamStdin  = 0
+	streamStdout = 1
+	streamStderr = 2
+	streamExit   = 3
+)
+
+var (
+	flagDetach bool
+	flagNoTTY  bool
+)
+
+// processDetails is a one-off process of a site.
+type processDetails struct {
+	Name     string   `json:"name"`
+	Command  []string `json:"command"`
+	Status   string   `json:"status"`
+	TTY      bool     `json:"tty"`
+	Detach   bool     `json:"detach"`
+	Width    int      `json:"width,omitempty"`
+	Height   int      `json:"height,omitempty"`
+	ExitCode *int     `json:"exit_code,omitempty"`
+}
+
+func init() {
+	RootCmd.AddCommand(runCmd)
+	runCmd.Flags().BoolVarP(&flagDetach, "detach", "d", false, "Run in the background (view output with kel logs)")
+	runCmd.Flags().BoolVarP(&flagNoTTY, "no-tty", "T", false, "Do not allocate a TTY even when attached to a terminal")
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run a one-off process with the release and environment of the site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel run [--detach] [--no-tty] -- <command> [args...]\n")
+			fatal(msg)
+		}
+		if len(args) < 1 {
+			usage("too few arguments")
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		stdin := int(os.Stdin.Fd())
+		tty := !flagDetach && !flagNoTTY && terminal.IsTerminal(stdin) && terminal.IsTerminal(int(os.Stdout.Fd()))
+		process := processDetails{
+			Command: args,
+			TTY:     tty,
+			Detach:  flagDetach,
+		}
+		if tty {
+			process.Width, process.Height, _ = terminal.GetSize(stdin)
+		}
+		api := setupAPIClient(uri)
+		if err := api.Do("POST", sitePath(uri)+"processes/", "processes", &process, &process); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to start process (error: %v)", err))
+		}
+		if flagDetach {
+			success(fmt.Sprintf("started process %s (view its output with kel logs --instance %s).", process.Name, process.Name))
+			return
+		}
+		code, err := attachProcess(api, processPath(uri, process.Name), tty)
+		if err != nil {
+			fatal(fmt.Sprintf("failed to attach to process %s (error: %v)", process.Name, err))
+		}
+		os.Exit(code)
+	},
+}
+
+// processPath returns the API path of a one-off process of the site.
+func processPath(uri URI, name string) string {
+	return fmt.Sprintf("%sprocesses/%s/", sitePath(uri), name)
+}
+
+// attachProcess connects the local stdio to a remote process until it exits
+// and returns its exit code. With tty the local terminal is put in raw mode
+// and its size is kept in sync; otherwise signals are forwarded.
+func attachProcess(api *apiClient, path string, tty bool) (int, error) {
+	conn, err := api.Upgrade(path + "attach/")
+	if err != nil {
+		return -1, err
+	}
+	defer conn.Close()
+	if tty {
+		stdin := int(os.Stdin.Fd())
+		state, err := terminal.MakeRaw(stdin)
+		if err != nil {
+			return -1, err
+		}
+		defer terminal.Restore(stdin, state)
+	}
+	// the remote process shares no terminal with kel, so interrupts are
+	// forwarded too
+	watched := append(append([]os.Signal{}, interruptSignals...), forwardedSignals...)
+	if tty {
+		// in raw mode interrupts reach the process as input
+		watched = resizeSignals
+	}
+	signals := make(chan os.Signal, 1)
+	if len(watched) > 0 {
+		// notifying for no signals would catch all of them
+		signal.Notify(signals, watched...)
+		defer signal.Stop(signals)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if tty {
+					width, height, err := terminal.GetSize(int(os.Stdin.Fd()))
+					if err == nil {
+						api.Do("POST", path+"resize/", "processes", map[string]int{"width": width, "height": height}, nil)
+					}
+				} else {
+					api.Do("POST", path+"signal/", "processes", map[string]string{"signal": remoteSignalName(sig)}, nil)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var mu sync.Mutex
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				mu.Lock()
+				werr := writeFrame(conn, streamStdin, buf[:n])
+				mu.Unlock()
+				if werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				mu.Lock()
+				writeFrame(conn, streamStdin, nil)
+				mu.Unlock()
+				return
+			}
+		}
+	}()
+	return readFrames(bufio.NewReader(conn))
+}
+
+// readFrames copies output frames to stdout and stderr until the exit
+// frame.
+func readFrames(r io.Reader) (int, error) {
+	var header [5]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return -1, fmt.Errorf("connection closed before the process exited (%v)", err)
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return -1, err
+		}
+		switch header[0] {
+		case streamStdout:
+			os.Stdout.Write(payload)
+		case streamStderr:
+			os.Stderr.Write(payload)
+		case streamExit:
+			code, err := strconv.Atoi(strings.TrimSpace(string(payload)))
+			if err != nil {
+				return -1, fmt.Errorf("invalid exit code %q", payload)
+			}
+			return code, nil
+		}
+	}
+}
+
+func writeFrame(w io.Writer, stream byte, payload []byte) error {
+	var header [5]byte
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// Upgrade switches the connection for path to the attach protocol and
+// returns it for reading and writing. The connection is dialed directly since
+// the body of a response read through the http.Client cannot be written to.
+func (c *apiClient) Upgrade(path string) (io.ReadWriteCloser, error) {
+	req, err := http.NewRequest("POST", c.url(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "kel-attach")
+	if config.Auth == AuthCluster {
+		ts := getClusterTokenSource()
+		if ts == nil {
+			return nil, fmt.Errorf("not logged in")
+		}
+		token, err := ts.Token()
+		if err != nil {
+			return nil, err
+		}
+		token.SetAuthHeader(req)
+	}
+	conn, err := dialURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer conn.Close()
+		defer resp.Body.Close()
+		if _, err := decodeAPIResponse(resp); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return &upgradedConn{Conn: conn, r: br}, nil
+}
+
+// dialURL opens a connection to the host of u, using TLS for https.
+func dialURL(u *url.URL) (net.Conn, error) {
+	host := u.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(host, "443")
+		} else {
+			host = net.JoinHostPort(host, "80")
+		}
+	}
+	if u.Scheme == "https" {
+		return tls.Dial("tcp", host, nil)
+	}
+	return net.Dial("tcp", host)
+}
+
+// upgradedConn reads through the buffer used to read the upgrade response,
+// which may hold the first frames sent by the site.
+type upgradedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *upgradedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import "os"
+
+// Windows consoles have no resize signal; the initial size is sent when the
+// process starts.
+var resizeSignals []os.Signal
+
+// remoteSignalName returns the name of a signal forwarded to a site process.
+func remoteSignalName(sig os.Signal) string {
+	return "SIGINT"
+}
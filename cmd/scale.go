@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+// scalePollInterval is how often kel scale --wait checks instance health.
+const scalePollInterval = 2 * time.Second
+
+var (
+	flagShow    bool
+	flagWait    bool
+	flagTimeout time.Duration
+)
+
+// processFormation is the number of instances a site runs of a process type.
+type processFormation struct {
+	ProcessType string `json:"process_type"`
+	Instances   int    `json:"instances"`
+	Healthy     int    `json:"healthy"`
+}
+
+// resourceGroupQuota holds the instance quota attributes of a resource
+// group, limiting the instances run by all its sites. A zero limit means
+// unlimited.
+type resourceGroupQuota struct {
+	MaxInstances int `json:"max_instances"`
+	Instances    int `json:"instances"`
+}
+
+func init() {
+	RootCmd.AddCommand(scaleCmd)
+	scaleCmd.Flags().BoolVarP(&flagShow, "show", "", false, "Show the current instance counts")
+	scaleCmd.Flags().BoolVarP(&flagWait, "wait", "", false, "Wait until the new instances are healthy")
+	scaleCmd.Flags().DurationVarP(&flagTimeout, "timeout", "", 5*time.Minute, "How long --wait waits")
+}
+
+var scaleCmd = &cobra.Command{
+	Use:   "scale",
+	Short: "Change the number of instances of the activated site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel scale [--wait [--timeout <duration>]] <process-type>=<instances>...\n")
+			fmt.Fprintf(os.Stderr, "       kel scale --show\n")
+			fatal(msg)
+		}
+		if flagShow && len(args) > 0 {
+			usage("--show does not take arguments")
+		}
+		if !flagShow && len(args) == 0 {
+			usage("too few arguments")
+		}
+		changes := make(map[string]int)
+		for _, arg := range args {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				usage(fmt.Sprintf("invalid argument %q", arg))
+			}
+			n, err := strconv.Atoi(parts[1])
+			if err != nil || n < 0 {
+				usage(fmt.Sprintf("invalid instance count %q", parts[1]))
+			}
+			changes[parts[0]] = n
+		}
+		uri, err := LookupSiteURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		api := setupAPIClient(uri)
+		formation := fetchFormation(api, uri)
+		if flagShow {
+			printFormation(formation)
+			return
+		}
+		current := make(map[string]int)
+		for _, process := range formation {
+			current[process.ProcessType] = process.Instances
+		}
+		delta := 0
+		for processType, n := range changes {
+			if _, ok := current[processType]; !ok {
+				fatal(fmt.Sprintf("site has no %q process type.", processType))
+			}
+			delta += n - current[processType]
+		}
+		checkInstanceQuota(api, uri, delta)
+		if err := api.Do("PATCH", sitePath(uri)+"formation/", "formation", map[string]interface{}{"instances": changes}, nil); err != nil {
+			fatal(fmt.Sprintf("failed to scale site (error: %v)", err))
+		}
+		for _, processType := range sortedKeys(changes) {
+			fmt.Printf("Scaled %s from %d to %s\n", processType, current[processType], whiteBold(strconv.Itoa(changes[processType])))
+		}
+		if flagWait {
+			fmt.Printf("Waiting for instances to become healthy... ")
+			if err := waitForFormation(api, uri, changes, flagTimeout); err != nil {
+				fmt.Println(red("error"))
+				fatal(err.Error())
+			}
+			fmt.Println(green("done"))
+		}
+		success(fmt.Sprintf("scaled %s/%s.", uri.ResourceGroup, uri.Site))
+	},
+}
+
+// fetchFormation returns the process types of a site sorted by name.
+func fetchFormation(api *apiClient, uri URI) []*processFormation {
+	var formation []*processFormation
+	if err := api.List(sitePath(uri)+"formation/", &formation); err != nil {
+		if err == kel.ErrNotFound {
+			fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+		}
+		fatal(fmt.Sprintf("failed to get instance counts (error: %v)", err))
+	}
+	sort.Sort(formationByType(formation))
+	return formation
+}
+
+func printFormation(formation []*processFormation) {
+	if flagOutput == OutputJSON {
+		printJSON(formation)
+		return
+	}
+	w := newTabWriter()
+	fmt.Fprintf(w, "PROCESS TYPE\tINSTANCES\tHEALTHY\n")
+	for _, process := range formation {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", process.ProcessType, process.Instances, process.Healthy)
+	}
+	w.Flush()
+}
+
+// checkInstanceQuota exits with an error when adding delta instances would
+// exceed the quota of the resource group. When the quota cannot be read a
+// warning is printed and the cluster is left to enforce it.
+func checkInstanceQuota(api *apiClient, uri URI, delta int) {
+	if delta <= 0 {
+		return
+	}
+	var quota resourceGroupQuota
+	if err := api.Do("GET", resourceGroupPath(uri.ResourceGroup), "", nil, &quota); err != nil {
+		if err == kel.ErrNotFound {
+			fatal(fmt.Sprintf("resource group %q does not exist.", uri.ResourceGroup))
+		}
+		warning(fmt.Sprintf("could not check the instance quota of resource group %q (error: %v)", uri.ResourceGroup, err))
+		return
+	}
+	if quota.MaxInstances > 0 && quota.Instances+delta > quota.MaxInstances {
+		fatal(fmt.Sprintf("resource group %q would run %d instances, exceeding its quota of %d.", uri.ResourceGroup, quota.Instances+delta, quota.MaxInstances))
+	}
+}
+
+// waitForFormation polls until the scaled process types run the requested
+// number of healthy instances.
+func waitForFormation(api *apiClient, uri URI, changes map[string]int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var formation []*processFormation
+		if err := api.List(sitePath(uri)+"formation/", &formation); err != nil {
+			return fmt.Errorf("failed to get instance counts (error: %v)", err)
+		}
+		ready := true
+		var pending []string
+		for _, process := range formation {
+			if n, ok := changes[process.ProcessType]; ok && (process.Instances != n || process.Healthy != n) {
+				ready = false
+				pending = append(pending, fmt.Sprintf("%s %d/%d", process.ProcessType, process.Healthy, n))
+			}
+		}
+		if ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for healthy instances (%s)", timeout, strings.Join(pending, ", "))
+		}
+		time.Sleep(scalePollInterval)
+	}
+}
+
+type formationByType []*processFormation
+
+func (s formationByType) Len() int           { return len(s) }
+func (s formationByType) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s formationByType) Less(i, j int) bool { return s[i].ProcessType < s[j].ProcessType }
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,15 +3,18 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/kelproject/kel-go"
+	kelplugin "github.com/kelproject/kel/plugin"
 	"github.com/spf13/cobra"
 )
 
 var (
 	flagResourceGroupName string
 	flagForce             bool
+	flagYes               bool
 )
 
 func init() {
@@ -19,7 +22,9 @@ func init() {
 	sitesCmd.AddCommand(
 		sitesCreateCmd,
 		sitesListCmd,
+		sitesDeleteCmd,
 	)
+	sitesDeleteCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Delete without asking for confirmation")
 	sitesCmd.PersistentFlags().StringVarP(&flagResourceGroupName, "resource-group", "", "", "Name of resource group")
 
 	RootCmd.AddCommand(activateCmd)
@@ -45,17 +50,8 @@ var sitesCreateCmd = &cobra.Command{
 			fatal(err.Error())
 		}
 		if len(args) == 1 {
-			switch strings.Count(args[0], "/") {
-			case 0:
-				uri.Site = args[0]
-				break
-			case 1:
-				parts := strings.Split(args[0], "/")
-				uri.ResourceGroup = parts[0]
-				uri.Site = parts[1]
-				break
-			default:
-				usage("invalid resource group / site pair")
+			if err := uri.SetSite(args[0]); err != nil {
+				usage(err.Error())
 			}
 		} else if len(args) > 1 {
 			usage("too many arguments")
@@ -87,7 +83,7 @@ var sitesListCmd = &cobra.Command{
 	Short: "List sites",
 	Run: func(cmd *cobra.Command, args []string) {
 		usage := func(msg string) {
-			fmt.Fprintf(os.Stderr, "Usage: kel sites list <resource-group>\n")
+			fmt.Fprintf(os.Stderr, "Usage: kel sites list [--all] [--filter field=glob] [--sort field] <resource-group>\n")
 			fatal(msg)
 		}
 		uri, err := LookupURI()
@@ -95,13 +91,38 @@ var sitesListCmd = &cobra.Command{
 			usage(err.Error())
 		}
 		if len(args) == 1 {
+			if flagAll {
+				usage("resource group cannot be given with --all")
+			}
 			uri.ResourceGroup = args[0]
 		} else if len(args) > 1 {
 			usage("too many arguments")
 		}
-		if uri.ResourceGroup == "" {
+		if uri.ResourceGroup == "" && !flagAll {
 			usage("missing resource group (specify with optional argument or in URI)")
 		}
+		if siteListRequested(cmd) {
+			filters, err := parseSiteFilters(flagFilters)
+			if err != nil {
+				usage(err.Error())
+			}
+			resourceGroups := []string{uri.ResourceGroup}
+			if flagAll {
+				if resourceGroups, err = accessibleResourceGroups(setupAPIClient(uri)); err != nil {
+					fatal(fmt.Sprintf("failed to list resource groups (error: %v)", err))
+				}
+			}
+			sites, err := listSites(uri, resourceGroups)
+			if err != nil {
+				fatal(fmt.Sprintf("failed to list sites (error: %v)", err))
+			}
+			sites = filterSites(sites, filters)
+			if err := sortSites(sites, flagSort, flagReverse); err != nil {
+				usage(err.Error())
+			}
+			printSites(sites)
+			return
+		}
 		kc := setupKelClient(uri)
 		var resourceGroup kel.ResourceGroup
 		if err := kc.ResourceGroups.Get(uri.ResourceGroup, &resourceGroup).Do(); err != nil {
@@ -120,6 +141,96 @@ var sitesListCmd = &cobra.Command{
 	},
 }
 
+var sitesDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel sites delete [--yes] <resource-group>/<site>\n")
+			fatal(msg)
+		}
+		uri, err := LookupURI()
+		if err != nil {
+			fatal(err.Error())
+		}
+		if len(args) == 1 {
+			if err := uri.SetSite(args[0]); err != nil {
+				usage(err.Error())
+			}
+		} else if len(args) > 1 {
+			usage("too many arguments")
+		}
+		if uri.ResourceGroup == "" || uri.Site == "" {
+			usage("must specify resource group and site.")
+		}
+		kc := setupKelClient(uri)
+		getSite(kc, uri)
+		var activations []string
+		for dir, siteConfig := range config.Sites {
+			if siteConfig.URI != nil && uri.Equals(*siteConfig.URI) {
+				activations = append(activations, dir)
+			}
+		}
+		sort.Strings(activations)
+		fmt.Fprintf(os.Stderr, "This will permanently delete the site %s on %s:\n", whiteBold(uri.ResourceGroup+"/"+uri.Site), uri.Host)
+		printSiteContents(setupAPIClient(uri), uri)
+		for _, dir := range activations {
+			fmt.Fprintf(os.Stderr, "The activation of %s will be removed.\n", dir)
+		}
+		if !flagYes {
+			if prompt(fmt.Sprintf("Type the site name (%s) to confirm:", uri.Site)) != uri.Site {
+				fatal("confirmation did not match; the site was not deleted.")
+			}
+		}
+		if err := setupAPIClient(uri).Do("DELETE", sitePath(uri), "", nil, nil); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to delete site (error: %v)", err))
+		}
+		for _, dir := range activations {
+			delete(config.Sites, dir)
+		}
+		config.Save()
+		success(fmt.Sprintf("deleted %q site.", fmt.Sprintf("%s/%s", uri.ResourceGroup, uri.Site)))
+	},
+}
+
+// printSiteContents prints to stderr the releases, instances and domains
+// deleting the site named by uri would destroy.
+func printSiteContents(api *apiClient, uri URI) {
+	releases := fetchReleases(api, uri)
+	if len(releases) == 0 {
+		fmt.Fprintf(os.Stderr, "  releases:  none\n")
+	} else {
+		latest := releases[0]
+		fmt.Fprintf(os.Stderr, "  releases:  %d, latest v%d (%s, %s)\n", len(releases), latest.Version, latest.Description, formatTime(latest.Created))
+	}
+	var instances []string
+	for _, process := range fetchFormation(api, uri) {
+		if process.Instances > 0 {
+			instances = append(instances, fmt.Sprintf("%s=%d", process.ProcessType, process.Instances))
+		}
+	}
+	if len(instances) == 0 {
+		instances = append(instances, "none")
+	}
+	fmt.Fprintf(os.Stderr, "  instances: %s\n", strings.Join(instances, " "))
+	var domains []*domainDetails
+	if err := api.List(sitePath(uri)+"domains/", &domains); err != nil {
+		fatal(fmt.Sprintf("failed to list domains (error: %v)", err))
+	}
+	names := []string{"none"}
+	if len(domains) > 0 {
+		names = names[:0]
+		for _, domain := range domains {
+			names = append(names, domain.Name)
+		}
+		sort.Strings(names)
+	}
+	fmt.Fprintf(os.Stderr, "  domains:   %s\n", strings.Join(names, " "))
+}
+
 var activateCmd = &cobra.Command{
 	Use:   "activate",
 	Short: "Activate a site",
@@ -133,17 +244,8 @@ var activateCmd = &cobra.Command{
 			fatal(err.Error())
 		}
 		if len(args) == 1 {
-			switch strings.Count(args[0], "/") {
-			case 0:
-				uri.Site = args[0]
-				break
-			case 1:
-				parts := strings.Split(args[0], "/")
-				uri.ResourceGroup = parts[0]
-				uri.Site = parts[1]
-				break
-			default:
-				usage("invalid resource group / site pair")
+			if err := uri.SetSite(args[0]); err != nil {
+				usage(err.Error())
 			}
 		} else if len(args) > 1 {
 			usage("too many arguments")
@@ -164,30 +266,24 @@ var activateCmd = &cobra.Command{
 			}
 			fatal(msg + ". Use --force to override.")
 		}
-		kc := setupKelClient(uri)
-		var resourceGroup kel.ResourceGroup
-		if err := kc.ResourceGroups.Get(uri.ResourceGroup, &resourceGroup).Do(); err != nil {
-			if err == kel.ErrNotFound {
-				fatal(fmt.Sprintf("resource group %q does not exist.", uri.ResourceGroup))
-			}
-			fatal(fmt.Sprintf("failed to get resource group (error: %v)", err))
-		}
-		site := kel.Site{
-			ResourceGroup: &resourceGroup,
-		}
-		if err := kc.Sites.Get(uri.Site, &site).Do(); err != nil {
-			if err == kel.ErrNotFound {
-				fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
-			}
-			fatal(fmt.Sprintf("failed to get site (error: %v)", err))
-		}
-		config.Sites[cwd] = &SiteConfig{URI: &uri}
-		config.Save()
-		SyncSitePlugins(&site)
+		activateSite(uri, cwd)
 		success(fmt.Sprintf("%s/%s has been activated.", uri.ResourceGroup, uri.Site))
 	},
 }
 
+// activateSite installs the plugins of the site named by uri and activates
+// it for dir.
+func activateSite(uri URI, dir string) {
+	kc := setupKelClient(uri)
+	site := getSite(kc, uri)
+	siteConfig := &SiteConfig{URI: &uri}
+	SyncSitePlugins(site, siteConfig)
+	// only record the activation once its plugins are installed
+	config.Sites[dir] = siteConfig
+	config.Save()
+	runHooks(kelplugin.HookPostActivate, siteConfig)
+}
+
 var deactivateCmd = &cobra.Command{
 	Use:   "deactivate",
 	Short: "Deactivate a site",
@@ -196,14 +292,38 @@ var deactivateCmd = &cobra.Command{
 		if err != nil {
 			fatal(fmt.Sprintf("failed to get current working directory (%s)", err.Error()))
 		}
-		if _, ok := config.Sites[cwd]; !ok {
+		siteConfig, ok := config.Sites[cwd]
+		if !ok {
 			fatal("nothing to delete")
 		}
+		runHooks(kelplugin.HookPreDeactivate, siteConfig)
 		delete(config.Sites, cwd)
 		config.Save()
+		runHooks(kelplugin.HookPostDeactivate, siteConfig)
 	},
 }
 
+// getSite will fetch the site named by uri or exit with an error.
+func getSite(kc *kel.Client, uri URI) *kel.Site {
+	var resourceGroup kel.ResourceGroup
+	if err := kc.ResourceGroups.Get(uri.ResourceGroup, &resourceGroup).Do(); err != nil {
+		if err == kel.ErrNotFound {
+			fatal(fmt.Sprintf("resource group %q does not exist.", uri.ResourceGroup))
+		}
+		fatal(fmt.Sprintf("failed to get resource group (error: %v)", err))
+	}
+	site := kel.Site{
+		ResourceGroup: &resourceGroup,
+	}
+	if err := kc.Sites.Get(uri.Site, &site).Do(); err != nil {
+		if err == kel.ErrNotFound {
+			fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+		}
+		fatal(fmt.Sprintf("failed to get site (error: %v)", err))
+	}
+	return &site
+}
+
 // GetActivatedSiteConfig will return the activated site config or nil
 func GetActivatedSiteConfig() *SiteConfig {
 	cwd, err := os.Getwd()
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// siteListWorkers bounds the number of resource groups listed concurrently.
+const siteListWorkers = 8
+
+var (
+	flagAll     bool
+	flagFilters []string
+	flagSort    string
+	flagReverse bool
+)
+
+func init() {
+	sitesListCmd.Flags().BoolVarP(&flagAll, "all", "", false, "List sites of every accessible resource group")
+	sitesListCmd.Flags().StringSliceVarP(&flagFilters, "filter", "", nil, "Only list sites matching field=glob (fields: name, resource-group, status)")
+	sitesListCmd.Flags().StringVarP(&flagSort, "sort", "", "resource-group", "Sort by name, resource-group, status or last-deploy")
+	sitesListCmd.Flags().BoolVarP(&flagReverse, "reverse", "", false, "Reverse the sort order")
+}
+
+// siteListRequested reports whether sites list should render the detailed
+// table rather than the plain list of names.
+func siteListRequested(cmd *cobra.Command) bool {
+	return flagAll || len(flagFilters) > 0 || flagOutput == OutputJSON || cmd.Flags().Changed("sort") || flagReverse
+}
+
+// listSites fetches the sites of the given resource groups concurrently.
+func listSites(uri URI, resourceGroups []string) ([]*siteDetails, error) {
+	api := setupAPIClient(uri)
+	results := make([][]*siteDetails, len(resourceGroups))
+	errs := make([]error, len(resourceGroups))
+	sem := make(chan struct{}, siteListWorkers)
+	var wg sync.WaitGroup
+	for i := range resourceGroups {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			var sites []*siteDetails
+			if err := api.List(sitesPath(resourceGroups[i]), &sites); err != nil {
+				errs[i] = fmt.Errorf("resource group %q: %v", resourceGroups[i], err)
+				return
+			}
+			for _, site := range sites {
+				if site.ResourceGroup == "" {
+					site.ResourceGroup = resourceGroups[i]
+				}
+			}
+			results[i] = sites
+		}(i)
+	}
+	wg.Wait()
+	var all []*siteDetails
+	for i := range resourceGroups {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		all = append(all, results[i]...)
+	}
+	return all, nil
+}
+
+// accessibleResourceGroups returns the names of every resource group the
+// user can access, following every page of the listing.
+func accessibleResourceGroups(api *apiClient) ([]string, error) {
+	var resourceGroups []*struct {
+		Name string `json:"name"`
+	}
+	if err := api.List("/resource-groups/", &resourceGroups); err != nil {
+		return nil, err
+	}
+	names := make([]string, len(resourceGroups))
+	for i := range resourceGroups {
+		names[i] = resourceGroups[i].Name
+	}
+	return names, nil
+}
+
+// siteFilter matches a site field against a glob pattern.
+type siteFilter struct {
+	field   string
+	pattern string
+}
+
+func parseSiteFilters(filters []string) ([]siteFilter, error) {
+	var parsed []siteFilter
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid filter %q (must be field=glob)", filter)
+		}
+		switch parts[0] {
+		case "name", "resource-group", "status":
+			break
+		default:
+			return nil, fmt.Errorf("invalid filter field %q (must be name, resource-group or status)", parts[0])
+		}
+		if _, err := path.Match(parts[1], ""); err != nil {
+			return nil, fmt.Errorf("invalid filter pattern %q", parts[1])
+		}
+		parsed = append(parsed, siteFilter{field: parts[0], pattern: parts[1]})
+	}
+	return parsed, nil
+}
+
+func (filter siteFilter) match(site *siteDetails) bool {
+	var value string
+	switch filter.field {
+	case "name":
+		value = site.Name
+	case "resource-group":
+		value = site.ResourceGroup
+	case "status":
+		value = site.Status
+	}
+	matched, _ := path.Match(filter.pattern, value)
+	return matched
+}
+
+// filterSites returns the sites matching every filter.
+func filterSites(sites []*siteDetails, filters []siteFilter) []*siteDetails {
+	var matched []*siteDetails
+	for _, site := range sites {
+		ok := true
+		for _, filter := range filters {
+			if !filter.match(site) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, site)
+		}
+	}
+	return matched
+}
+
+// sortSites sorts sites by the given field, breaking ties by resource group
+// and name.
+func sortSites(sites []*siteDetails, field string, reverse bool) error {
+	var less func(a, b *siteDetails) bool
+	switch field {
+	case "name":
+		less = func(a, b *siteDetails) bool { return a.Name < b.Name }
+	case "resource-group":
+		less = func(a, b *siteDetails) bool { return a.ResourceGroup < b.ResourceGroup }
+	case "status":
+		less = func(a, b *siteDetails) bool { return a.Status < b.Status }
+	case "last-deploy":
+		less = func(a, b *siteDetails) bool { return a.LastDeploy.Before(b.LastDeploy) }
+	default:
+		return fmt.Errorf("invalid sort field %q (must be name, resource-group, status or last-deploy)", field)
+	}
+	sort.Sort(sitesBy{sites: sites, less: func(a, b *siteDetails) bool {
+		if less(a, b) != less(b, a) {
+			return less(a, b) != reverse
+		}
+		if a.ResourceGroup != b.ResourceGroup {
+			return a.ResourceGroup < b.ResourceGroup
+		}
+		return a.Name < b.Name
+	}})
+	return nil
+}
+
+type sitesBy struct {
+	sites []*siteDetails
+	less  func(a, b *siteDetails) bool
+}
+
+func (s sitesBy) Len() int           { return len(s.sites) }
+func (s sitesBy) Swap(i, j int)      { s.sites[i], s.sites[j] = s.sites[j], s.sites[i] }
+func (s sitesBy) Less(i, j int) bool { return s.less(s.sites[i], s.sites[j]) }
+
+// printSites renders sites as a table or JSON.
+func printSites(sites []*siteDetails) {
+	if flagOutput == OutputJSON {
+		printJSON(sites)
+		return
+	}
+	w := newTabWriter()
+	fmt.Fprintf(w, "RESOURCE GROUP\tSITE\tSTATUS\tLAST DEPLOY\n")
+	for _, site := range sites {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", site.ResourceGroup, site.Name, site.Status, formatTime(site.LastDeploy))
+	}
+	w.Flush()
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	sitesCmd.AddCommand(
+		sitesRenameCmd,
+		sitesMoveCmd,
+	)
+}
+
+var sitesRenameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "Rename a site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel sites rename <resource-group>/<site> <new-name>\n")
+			fatal(msg)
+		}
+		if len(args) < 2 {
+			usage("too few arguments")
+		}
+		if len(args) > 2 {
+			usage("too many arguments")
+		}
+		uri := siteArgURI(args[0], usage)
+		if !validResourceName(args[1]) {
+			usage(fmt.Sprintf("invalid site name %q (use lowercase letters, digits and dashes; kel sites move changes the resource group)", args[1]))
+		}
+		to := uri
+		to.Site = args[1]
+		if to.Site == uri.Site {
+			fatal(fmt.Sprintf("site is already named %q.", uri.Site))
+		}
+		getSite(setupKelClient(uri), uri)
+		if err := setupAPIClient(uri).Do("PATCH", sitePath(uri), "sites", map[string]string{"name": to.Site}, nil); err != nil {
+			fatal(fmt.Sprintf("failed to rename site (error: %v)", err))
+		}
+		rewriteActivations(uri, to)
+		success(fmt.Sprintf("renamed %q site to %q.", uri.ResourceGroup+"/"+uri.Site, to.ResourceGroup+"/"+to.Site))
+	},
+}
+
+var sitesMoveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Move a site to another resource group",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel sites move <resource-group>/<site> <other-resource-group>\n")
+			fatal(msg)
+		}
+		if len(args) < 2 {
+			usage("too few arguments")
+		}
+		if len(args) > 2 {
+			usage("too many arguments")
+		}
+		uri := siteArgURI(args[0], usage)
+		if !validResourceName(args[1]) {
+			usage(fmt.Sprintf("invalid resource group name %q", args[1]))
+		}
+		to := uri
+		to.ResourceGroup = args[1]
+		if to.ResourceGroup == uri.ResourceGroup {
+			fatal(fmt.Sprintf("site is already in resource group %q.", uri.ResourceGroup))
+		}
+		kc := setupKelClient(uri)
+		getSite(kc, uri)
+		var resourceGroup kel.ResourceGroup
+		if err := kc.ResourceGroups.Get(to.ResourceGroup, &resourceGroup).Do(); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("resource group %q does not exist.", to.ResourceGroup))
+			}
+			fatal(fmt.Sprintf("failed to get resource group (error: %v)", err))
+		}
+		if err := setupAPIClient(uri).Do("PATCH", sitePath(uri), "sites", map[string]string{"resource_group": to.ResourceGroup}, nil); err != nil {
+			fatal(fmt.Sprintf("failed to move site (error: %v)", err))
+		}
+		rewriteActivations(uri, to)
+		success(fmt.Sprintf("moved %q site to %q.", uri.ResourceGroup+"/"+uri.Site, to.ResourceGroup+"/"+to.Site))
+	},
+}
+
+// siteArgURI returns the URI of the site named by a <resource-group>/<site>
+// argument.
+func siteArgURI(arg string, usage func(string)) URI {
+	uri, err := LookupURI()
+	if err != nil {
+		fatal(err.Error())
+	}
+	if err := uri.SetSite(arg); err != nil {
+		usage(err.Error())
+	}
+	if uri.ResourceGroup == "" || uri.Site == "" {
+		usage("must specify resource group and site.")
+	}
+	return uri
+}
+
+// rewriteActivations points the activations of the site formerly at uri to
+// its new location.
+func rewriteActivations(uri, to URI) {
+	rewritten := 0
+	for _, siteConfig := range config.Sites {
+		if siteConfig.URI != nil && uri.Equals(*siteConfig.URI) {
+			siteConfig.URI.ResourceGroup = to.ResourceGroup
+			siteConfig.URI.Site = to.Site
+			rewritten++
+		}
+	}
+	if rewritten > 0 {
+		config.Save()
+		fmt.Printf("Updated %d activated directories.\n", rewritten)
+	}
+}
+
+// validResourceName reports whether name is a valid resource group or site
+// name: lowercase letters, digits and dashes, not starting or ending with a
+// dash.
+func validResourceName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kelproject/kel-go"
+	"github.com/spf13/cobra"
+)
+
+// siteDetails is the full representation of a site returned by the API.
+type siteDetails struct {
+	Name           string         `json:"name"`
+	ResourceGroup  string         `json:"resource_group"`
+	Status         string         `json:"status"`
+	Created        time.Time      `json:"created"`
+	LastDeploy     time.Time      `json:"last_deploy"`
+	CurrentRelease *siteRelease   `json:"current_release,omitempty"`
+	Instances      []siteInstance `json:"instances"`
+	Domains        []string       `json:"domains"`
+	Plugins        []sitePlugin   `json:"plugins"`
+}
+
+type siteRelease struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+type siteInstance struct {
+	Name        string `json:"name"`
+	ProcessType string `json:"process_type"`
+	Status      string `json:"status"`
+}
+
+type sitePlugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func init() {
+	sitesCmd.AddCommand(sitesShowCmd)
+}
+
+var sitesShowCmd = &cobra.Command{
+	Use:     "show",
+	Aliases: []string{"describe"},
+	Short:   "Show details of a site",
+	Run: func(cmd *cobra.Command, args []string) {
+		usage := func(msg string) {
+			fmt.Fprintf(os.Stderr, "Usage: kel sites show [<resource-group>/<site>]\n")
+			fatal(msg)
+		}
+		var uri URI
+		var err error
+		if len(args) == 1 {
+			if uri, err = LookupURI(); err != nil {
+				usage(err.Error())
+			}
+			if err := uri.SetSite(args[0]); err != nil {
+				usage(err.Error())
+			}
+		} else if len(args) > 1 {
+			usage("too many arguments")
+		} else if uri, err = LookupSiteURI(); err != nil {
+			usage(err.Error())
+		}
+		if uri.ResourceGroup == "" || uri.Site == "" {
+			usage("must specify resource group and site.")
+		}
+		var site siteDetails
+		if err := setupAPIClient(uri).Do("GET", sitePath(uri), "", nil, &site); err != nil {
+			if err == kel.ErrNotFound {
+				fatal(fmt.Sprintf("site %q does not exist.", uri.Site))
+			}
+			fatal(fmt.Sprintf("failed to get site (error: %v)", err))
+		}
+		if flagOutput == OutputJSON {
+			printJSON(&site)
+			return
+		}
+		w := newTabWriter()
+		fmt.Fprintf(w, "Site:\t%s\n", whiteBold(site.ResourceGroup+"/"+site.Name))
+		fmt.Fprintf(w, "Resource group:\t%s\n", site.ResourceGroup)
+		fmt.Fprintf(w, "Status:\t%s\n", site.Status)
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(site.Created))
+		fmt.Fprintf(w, "Last deploy:\t%s\n", formatTime(site.LastDeploy))
+		if site.CurrentRelease != nil {
+			fmt.Fprintf(w, "Current release:\tv%d (%s)\n", site.CurrentRelease.Version, formatTime(site.CurrentRelease.Created))
+		} else {
+			fmt.Fprintf(w, "Current release:\tnone\n")
+		}
+		fmt.Fprintf(w, "Instances:\t%d\n", len(site.Instances))
+		for _, instance := range site.Instances {
+			fmt.Fprintf(w, "  %s\t%s (%s)\n", instance.Name, instance.Status, instance.ProcessType)
+		}
+		if len(site.Domains) > 0 {
+			fmt.Fprintf(w, "Domains:\t%s\n", strings.Join(site.Domains, ", "))
+		} else {
+			fmt.Fprintf(w, "Domains:\tnone\n")
+		}
+		if len(site.Plugins) > 0 {
+			plugins := make([]string, len(site.Plugins))
+			for i, plugin := range site.Plugins {
+				plugins[i] = fmt.Sprintf("%s %s", plugin.Name, plugin.Version)
+			}
+			fmt.Fprintf(w, "Plugins:\t%s\n", strings.Join(plugins, ", "))
+		} else {
+			fmt.Fprintf(w, "Plugins:\tnone\n")
+		}
+		w.Flush()
+	},
+}
+
+// formatTime formats API timestamps for display in local time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05 MST")
+}
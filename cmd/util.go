@@ -63,14 +63,20 @@ func (uri URI) Equals(other URI) bool {
 	return uri.Host == other.Host && uri.ResourceGroup == other.ResourceGroup && uri.Site == other.Site
 }
 
+// String formats the URI so that ParseURI returns it unchanged.
 func (uri URI) String() string {
+	var s string
 	if uri.ResourceGroup == "" {
-		return fmt.Sprintf("//%s", uri.Host)
+		s = fmt.Sprintf("//%s", uri.Host)
+	} else if uri.Site == "" {
+		s = fmt.Sprintf("//%s/%s", uri.Host, uri.ResourceGroup)
+	} else {
+		s = fmt.Sprintf("//%s/%s/%s", uri.Host, uri.ResourceGroup, uri.Site)
 	}
-	if uri.Site == "" {
-		return fmt.Sprintf("//%s/%s", uri.Host, uri.ResourceGroup)
+	if uri.Insecure {
+		s += "?insecure=true"
 	}
-	return fmt.Sprintf("//%s/%s/%s", uri.Host, uri.ResourceGroup, uri.Site)
+	return s
 }
 
 // LookupURI will find the most relevant URI string and parse it.
@@ -84,3 +90,38 @@ func LookupURI() (URI, error) {
 	}
 	return uri, nil
 }
+
+// SetSite will set the site of the URI from a "site" or "resource-group/site"
+// argument.
+func (uri *URI) SetSite(arg string) error {
+	switch strings.Count(arg, "/") {
+	case 0:
+		uri.Site = arg
+	case 1:
+		parts := strings.Split(arg, "/")
+		uri.ResourceGroup = parts[0]
+		uri.Site = parts[1]
+	default:
+		return errors.New("invalid resource group / site pair")
+	}
+	return nil
+}
+
+// LookupSiteURI will find the URI of the site to operate on: the --uri flag
+// when it names a site, otherwise the site activated for this directory.
+func LookupSiteURI() (URI, error) {
+	if flagURI != "" {
+		uri, err := ParseURI(flagURI)
+		if err != nil {
+			return URI{}, err
+		}
+		if uri.ResourceGroup == "" || uri.Site == "" {
+			return URI{}, errors.New("--uri must name a resource group and site")
+		}
+		return uri, nil
+	}
+	if siteConfig := GetActivatedSiteConfig(); siteConfig != nil && siteConfig.URI != nil {
+		return *siteConfig.URI, nil
+	}
+	return URI{}, errors.New("no site is activated for this directory (activate one or use --uri)")
+}
@@ -0,0 +1,142 @@
+// Package plugin helps Kel client plugins read the execution context that the
+// kel command-line tool passes to them.
+//
+// kel runs a plugin with the following environment variables set in addition
+// to its own environment:
+//
+//	KEL_PLUGIN_PROTOCOL      version of this protocol (currently 1)
+//	KEL_VERSION              version of the kel client running the plugin
+//	KEL_URI                  resolved URI (//host/resource-group/site)
+//	KEL_RESOURCE_GROUP       resource group from the resolved URI
+//	KEL_SITE                 site from the resolved URI
+//	KEL_API_URL              base URL of the Kel API for the resolved URI
+//	KEL_ACCESS_TOKEN         short-lived OAuth2 access token, if logged in
+//	KEL_ACCESS_TOKEN_EXPIRY  expiry of the access token in RFC 3339 format
+//	KEL_OUTPUT               requested output format ("text" or "json")
+//	KEL_COLOR                "1" when colored output is allowed, "0" otherwise
+//
+// Variables that do not apply to an invocation are set to the empty string.
+//
+// Plugins declaring hooks in their manifest are also run as
+//
+//	<plugin> --kel-hook <event>
+//
+// with KEL_HOOK set to the event and, for pre-command hooks, KEL_COMMAND set
+// to the full kel command being run. Hook output is shown on stderr.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ProtocolVersion is the version of the context protocol implemented by this
+// package.
+const ProtocolVersion = 1
+
+// Environment variable names making up the context protocol.
+const (
+	EnvProtocol      = "KEL_PLUGIN_PROTOCOL"
+	EnvKelVersion    = "KEL_VERSION"
+	EnvURI           = "KEL_URI"
+	EnvResourceGroup = "KEL_RESOURCE_GROUP"
+	EnvSite          = "KEL_SITE"
+	EnvAPIURL        = "KEL_API_URL"
+	EnvAccessToken   = "KEL_ACCESS_TOKEN"
+	EnvTokenExpiry   = "KEL_ACCESS_TOKEN_EXPIRY"
+	EnvOutput        = "KEL_OUTPUT"
+	EnvColor         = "KEL_COLOR"
+	EnvHook          = "KEL_HOOK"
+	EnvCommand       = "KEL_COMMAND"
+)
+
+// ErrNoContext is returned when the process was not started by kel.
+var ErrNoContext = errors.New("plugin was not started by kel")
+
+// Context is the information kel passes to a plugin invocation.
+type Context struct {
+	Protocol      int
+	KelVersion    string
+	URI           string
+	ResourceGroup string
+	Site          string
+	APIURL        string
+	AccessToken   string
+	TokenExpiry   time.Time
+	Output        string
+	Color         bool
+}
+
+// FromEnv will read the context kel passed to the current process.
+func FromEnv() (*Context, error) {
+	protocol := os.Getenv(EnvProtocol)
+	if protocol == "" {
+		return nil, ErrNoContext
+	}
+	version, err := strconv.Atoi(protocol)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", EnvProtocol, protocol)
+	}
+	ctx := &Context{
+		Protocol:      version,
+		KelVersion:    os.Getenv(EnvKelVersion),
+		URI:           os.Getenv(EnvURI),
+		ResourceGroup: os.Getenv(EnvResourceGroup),
+		Site:          os.Getenv(EnvSite),
+		APIURL:        os.Getenv(EnvAPIURL),
+		AccessToken:   os.Getenv(EnvAccessToken),
+		Output:        os.Getenv(EnvOutput),
+		Color:         os.Getenv(EnvColor) == "1",
+	}
+	if expiry := os.Getenv(EnvTokenExpiry); expiry != "" {
+		if ctx.TokenExpiry, err = time.Parse(time.RFC3339, expiry); err != nil {
+			return nil, fmt.Errorf("invalid %s %q", EnvTokenExpiry, expiry)
+		}
+	}
+	return ctx, nil
+}
+
+// Environ returns the context encoded as environment variables in the form
+// "key=value".
+func (ctx *Context) Environ() []string {
+	var expiry string
+	if !ctx.TokenExpiry.IsZero() {
+		expiry = ctx.TokenExpiry.Format(time.RFC3339)
+	}
+	color := "0"
+	if ctx.Color {
+		color = "1"
+	}
+	return []string{
+		EnvProtocol + "=" + strconv.Itoa(ctx.Protocol),
+		EnvKelVersion + "=" + ctx.KelVersion,
+		EnvURI + "=" + ctx.URI,
+		EnvResourceGroup + "=" + ctx.ResourceGroup,
+		EnvSite + "=" + ctx.Site,
+		EnvAPIURL + "=" + ctx.APIURL,
+		EnvAccessToken + "=" + ctx.AccessToken,
+		EnvTokenExpiry + "=" + expiry,
+		EnvOutput + "=" + ctx.Output,
+		EnvColor + "=" + color,
+	}
+}
+
+// HTTPClient returns an HTTP client authenticating requests with the context
+// access token. Without a token http.DefaultClient is returned.
+func (ctx *Context) HTTPClient() *http.Client {
+	if ctx.AccessToken == "" {
+		return http.DefaultClient
+	}
+	ts := oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: ctx.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      ctx.TokenExpiry,
+	})
+	return oauth2.NewClient(oauth2.NoContext, ts)
+}
@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DescribeFlag is passed as the only argument when kel asks a plugin to
+// describe itself. The plugin must print a JSON encoded Description to
+// stdout and exit with status 0.
+const DescribeFlag = "--kel-describe"
+
+// CompleteFlag is passed as the first argument when kel asks a plugin for
+// shell completions. The remaining arguments are the words typed after the
+// plugin command, the last one being the (possibly empty) word to complete.
+// The plugin must print one candidate per line to stdout.
+const CompleteFlag = "--kel-complete"
+
+// Description describes a plugin command, its flags and its subcommands.
+type Description struct {
+	Use      string             `json:"use"`
+	Aliases  []string           `json:"aliases,omitempty"`
+	Short    string             `json:"short,omitempty"`
+	Long     string             `json:"long,omitempty"`
+	Example  string             `json:"example,omitempty"`
+	Flags    []*FlagDescription `json:"flags,omitempty"`
+	Commands []*Description     `json:"commands,omitempty"`
+	// Complete is set when the plugin answers CompleteFlag invocations for
+	// the arguments of this command.
+	Complete bool `json:"complete,omitempty"`
+}
+
+// FlagDescription describes a single command-line flag.
+type FlagDescription struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	// Type is either "bool" or "string" (the default).
+	Type    string `json:"type,omitempty"`
+	Default string `json:"default,omitempty"`
+	Usage   string `json:"usage,omitempty"`
+	// Complete is set when the plugin answers CompleteFlag invocations for
+	// values of this flag.
+	Complete bool `json:"complete,omitempty"`
+}
+
+// HandleDescribe will print d and exit when the plugin was invoked with
+// DescribeFlag. Plugins should call it first thing in main.
+func HandleDescribe(d *Description) {
+	if len(os.Args) != 2 || os.Args[1] != DescribeFlag {
+		return
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(d); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode description: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,25 @@
+package plugin
+
+import "os"
+
+// HookFlag is passed as the first argument when kel runs a plugin hook. The
+// second argument is the event.
+const HookFlag = "--kel-hook"
+
+// Events a plugin can hook into.
+const (
+	HookPostActivate   = "post-activate"
+	HookPreDeactivate  = "pre-deactivate"
+	HookPostDeactivate = "post-deactivate"
+	HookPreCommand     = "pre-command"
+	HookPostLogin      = "post-login"
+)
+
+// HookEvent returns the event kel invoked the plugin for, or the empty
+// string when the plugin was not run as a hook.
+func HookEvent() string {
+	if len(os.Args) != 3 || os.Args[1] != HookFlag {
+		return ""
+	}
+	return os.Args[2]
+}